@@ -0,0 +1,94 @@
+// Package api names the types exchanged with the manager and worker HTTP
+// APIs, so that code outside this module can use package client. They are
+// aliases of the types the manager and workers use themselves.
+package api
+
+import (
+	"github.com/nduyhai/maestro/internal/httpx"
+	"github.com/nduyhai/maestro/internal/node"
+	"github.com/nduyhai/maestro/internal/scheduler"
+	"github.com/nduyhai/maestro/internal/stats"
+	"github.com/nduyhai/maestro/internal/task"
+	"github.com/nduyhai/maestro/internal/worker"
+)
+
+// Tasks and their scheduling constraints.
+type (
+	Task               = task.Task
+	Event              = task.Event
+	State              = task.State
+	Group              = task.Group
+	RestartPolicy      = task.RestartPolicy
+	HealthCheck        = task.HealthCheck
+	Health             = task.Health
+	Selector           = task.Selector
+	Requirement        = task.Requirement
+	Operator           = task.Operator
+	Taint              = task.Taint
+	TaintEffect        = task.TaintEffect
+	Toleration         = task.Toleration
+	TolerationOperator = task.TolerationOperator
+	TopologySpread     = task.TopologySpread
+)
+
+const (
+	Pending   = task.Pending
+	Scheduled = task.Scheduled
+	Running   = task.Running
+	Completed = task.Completed
+	Failed    = task.Failed
+
+	RestartNever     = task.RestartNever
+	RestartOnFailure = task.RestartOnFailure
+	RestartAlways    = task.RestartAlways
+
+	HealthStarting  = task.HealthStarting
+	HealthHealthy   = task.HealthHealthy
+	HealthUnhealthy = task.HealthUnhealthy
+
+	In           = task.In
+	NotIn        = task.NotIn
+	Exists       = task.Exists
+	DoesNotExist = task.DoesNotExist
+
+	NoSchedule       = task.NoSchedule
+	PreferNoSchedule = task.PreferNoSchedule
+	NoExecute        = task.NoExecute
+
+	TolerationEqual  = task.TolerationEqual
+	TolerationExists = task.TolerationExists
+)
+
+// Nodes, as listed by the manager.
+type (
+	Node       = node.Node
+	NodeStatus = node.Status
+	Drain      = node.Drain
+)
+
+const (
+	NodeUnknown  = node.Unknown
+	NodeReady    = node.Ready
+	NodeNotReady = node.NotReady
+	NodeLost     = node.Lost
+)
+
+// Scheduling dry runs.
+type (
+	Explanation           = scheduler.Explanation
+	NodeExplanation       = scheduler.NodeExplanation
+	PreemptionExplanation = scheduler.PreemptionExplanation
+	Victim                = scheduler.Victim
+)
+
+// Workers, as they describe themselves to the manager.
+type (
+	Stats        = stats.Stats
+	WorkerInfo   = worker.Info
+	Registration = worker.Registration
+	Heartbeat    = worker.Heartbeat
+	TaskSummary  = worker.TaskSummary
+)
+
+// ErrResponse is the error returned by the client for an error response.
+type ErrResponse = httpx.ErrResponse
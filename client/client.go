@@ -0,0 +1,307 @@
+// Package client is a typed Go client for the maestro manager and worker HTTP
+// APIs. The types it exchanges are named in package api.
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nduyhai/maestro/api"
+	"resty.dev/v3"
+)
+
+const (
+	DefaultTimeout      = 10 * time.Second
+	DefaultRetries      = 2
+	DefaultPollInterval = 2 * time.Second
+)
+
+// Client calls either a manager or a worker. Both APIs expose the same task
+// routes, the manager under the /manager prefix.
+type Client struct {
+	rest         *resty.Client
+	ownsRest     bool
+	baseURL      string
+	prefix       string
+	timeout      time.Duration
+	retries      int
+	retryWait    time.Duration
+	pollInterval time.Duration
+}
+
+type Option func(*Client)
+
+// WithResty shares an existing resty client, and its connection pool, instead
+// of creating one. The caller stays responsible for closing it.
+func WithResty(rc *resty.Client) Option {
+	return func(c *Client) {
+		c.rest = rc
+		c.ownsRest = false
+	}
+}
+
+// WithTimeout bounds every request, retries included.
+func WithTimeout(d time.Duration) Option {
+	return func(c *Client) { c.timeout = d }
+}
+
+// WithRetries sets how many times idempotent requests are retried on
+// connection errors, 429 and 5xx responses, and the initial wait in between.
+func WithRetries(count int, wait time.Duration) Option {
+	return func(c *Client) {
+		c.retries = count
+		c.retryWait = wait
+	}
+}
+
+// WithPollInterval sets how often WatchEvents polls the manager.
+func WithPollInterval(d time.Duration) Option {
+	return func(c *Client) { c.pollInterval = d }
+}
+
+// New returns a client for the worker API at baseURL, e.g. http://localhost:8081.
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:      strings.TrimSuffix(baseURL, "/"),
+		timeout:      DefaultTimeout,
+		retries:      DefaultRetries,
+		retryWait:    100 * time.Millisecond,
+		pollInterval: DefaultPollInterval,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.rest == nil {
+		c.rest = resty.New()
+		c.ownsRest = true
+	}
+	return c
+}
+
+// NewManager returns a client for the manager API at baseURL, e.g. http://localhost:8080.
+func NewManager(baseURL string, opts ...Option) *Client {
+	c := New(baseURL, opts...)
+	c.prefix = "/manager"
+	return c
+}
+
+func (c *Client) Close() error {
+	if c.ownsRest {
+		return c.rest.Close()
+	}
+	return nil
+}
+
+func (c *Client) SubmitTask(ctx context.Context, te api.Event) (*api.Task, error) {
+	var t api.Task
+	err := c.do(ctx, http.MethodPost, c.prefix+"/tasks", nil, te, &t)
+	return &t, err
+}
+
+func (c *Client) ListTasks(ctx context.Context) ([]*api.Task, error) {
+	var tasks []*api.Task
+	err := c.do(ctx, http.MethodGet, c.prefix+"/tasks", nil, nil, &tasks)
+	return tasks, err
+}
+
+func (c *Client) GetTask(ctx context.Context, id uuid.UUID) (*api.Task, error) {
+	var t api.Task
+	err := c.do(ctx, http.MethodGet, c.prefix+"/tasks/"+id.String(), nil, nil, &t)
+	return &t, err
+}
+
+func (c *Client) StopTask(ctx context.Context, id uuid.UUID) error {
+	return c.do(ctx, http.MethodDelete, c.prefix+"/tasks/"+id.String(), nil, nil, nil)
+}
+
+// GetStats returns the host statistics of a worker.
+func (c *Client) GetStats(ctx context.Context) (*api.Stats, error) {
+	var s api.Stats
+	err := c.do(ctx, http.MethodGet, c.prefix+"/stats", nil, nil, &s)
+	return &s, err
+}
+
+// GetInfo returns the name and labels of a worker.
+func (c *Client) GetInfo(ctx context.Context) (*api.WorkerInfo, error) {
+	var info api.WorkerInfo
+	err := c.do(ctx, http.MethodGet, c.prefix+"/info", nil, nil, &info)
+	return &info, err
+}
+
+// Health checks that the API is up. It is served outside the /manager prefix.
+func (c *Client) Health(ctx context.Context) error {
+	return c.do(ctx, http.MethodGet, "/health", nil, nil, nil)
+}
+
+func (c *Client) ListNodes(ctx context.Context) ([]*api.Node, error) {
+	var nodes []*api.Node
+	err := c.do(ctx, http.MethodGet, c.prefix+"/nodes", nil, nil, &nodes)
+	return nodes, err
+}
+
+// AddTaint applies a taint to a node through the manager.
+func (c *Client) AddTaint(ctx context.Context, nodeName string, t api.Taint) error {
+	return c.do(ctx, http.MethodPost, c.prefix+"/nodes/"+url.PathEscape(nodeName)+"/taints", nil, t, nil)
+}
+
+// RemoveTaint removes the taints with the given key applied to a node through the manager.
+func (c *Client) RemoveTaint(ctx context.Context, nodeName, key string) error {
+	return c.do(ctx, http.MethodDelete, c.prefix+"/nodes/"+url.PathEscape(nodeName)+"/taints/"+url.PathEscape(key), nil, nil, nil)
+}
+
+// Cordon stops the manager from placing new tasks on a node.
+func (c *Client) Cordon(ctx context.Context, nodeName string) error {
+	return c.do(ctx, http.MethodPost, c.prefix+"/nodes/"+url.PathEscape(nodeName)+"/cordon", nil, nil, nil)
+}
+
+// Uncordon puts a cordoned or draining node back in rotation.
+func (c *Client) Uncordon(ctx context.Context, nodeName string) error {
+	return c.do(ctx, http.MethodDelete, c.prefix+"/nodes/"+url.PathEscape(nodeName)+"/cordon", nil, nil, nil)
+}
+
+// Drain cordons a node and has the manager move its tasks elsewhere, at most
+// maxUnavailable of them at a time.
+func (c *Client) Drain(ctx context.Context, nodeName string, maxUnavailable int) error {
+	return c.do(ctx, http.MethodPost, c.prefix+"/nodes/"+url.PathEscape(nodeName)+"/drain", nil, api.Drain{MaxUnavailable: maxUnavailable}, nil)
+}
+
+// Register announces a worker to the manager and returns the name of its node.
+func (c *Client) Register(ctx context.Context, r api.Registration) (string, error) {
+	var registered api.Registration
+	err := c.do(ctx, http.MethodPost, c.prefix+"/nodes", nil, r, &registered)
+	return registered.Name, err
+}
+
+func (c *Client) Heartbeat(ctx context.Context, nodeName string, hb api.Heartbeat) error {
+	return c.do(ctx, http.MethodPut, c.prefix+"/nodes/"+url.PathEscape(nodeName)+"/heartbeat", nil, hb, nil)
+}
+
+// SubmitGroup submits tasks that must all be placed at once.
+func (c *Client) SubmitGroup(ctx context.Context, g api.Group) (*api.Group, error) {
+	var submitted api.Group
+	err := c.do(ctx, http.MethodPost, c.prefix+"/groups", nil, g, &submitted)
+	return &submitted, err
+}
+
+func (c *Client) ListGroups(ctx context.Context) ([]*api.Group, error) {
+	var groups []*api.Group
+	err := c.do(ctx, http.MethodGet, c.prefix+"/groups", nil, nil, &groups)
+	return groups, err
+}
+
+// Explain asks the manager where t would be scheduled, without submitting it.
+func (c *Client) Explain(ctx context.Context, t api.Task) (*api.Explanation, error) {
+	var e api.Explanation
+	err := c.do(ctx, http.MethodPost, c.prefix+"/schedule/explain", nil, t, &e)
+	return &e, err
+}
+
+// ListEvents returns the events recorded by the manager, restricted to one
+// task unless taskID is uuid.Nil, and to events at or after since unless it is zero.
+func (c *Client) ListEvents(ctx context.Context, taskID uuid.UUID, since time.Time) ([]*api.Event, error) {
+	query := url.Values{}
+	if !since.IsZero() {
+		query.Set("since", since.Format(time.RFC3339Nano))
+	}
+	return c.listEvents(ctx, taskID, query)
+}
+
+// ListEventsAfter returns the events recorded by the manager after the one
+// numbered seq, restricted to one task unless taskID is uuid.Nil.
+func (c *Client) ListEventsAfter(ctx context.Context, taskID uuid.UUID, seq uint64) ([]*api.Event, error) {
+	query := url.Values{}
+	query.Set("after", strconv.FormatUint(seq, 10))
+	return c.listEvents(ctx, taskID, query)
+}
+
+func (c *Client) listEvents(ctx context.Context, taskID uuid.UUID, query url.Values) ([]*api.Event, error) {
+	if taskID != uuid.Nil {
+		query.Set("task", taskID.String())
+	}
+	var events []*api.Event
+	err := c.do(ctx, http.MethodGet, c.prefix+"/events", query, nil, &events)
+	return events, err
+}
+
+// WatchEvents polls the manager for new events and calls fn for each of them
+// in the order they were recorded. It blocks until ctx is done or a request
+// fails.
+func (c *Client) WatchEvents(ctx context.Context, taskID uuid.UUID, fn func(*api.Event)) error {
+	var seq uint64
+	ticker := time.NewTicker(c.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		events, err := c.ListEventsAfter(ctx, taskID, seq)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		for _, e := range events {
+			seq = max(seq, e.Seq)
+			fn(e)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out any) error {
+	req := c.rest.R().
+		SetContext(ctx).
+		SetTimeout(c.timeout).
+		SetRetryCount(c.retries).
+		SetRetryWaitTime(c.retryWait).
+		SetRetryDefaultConditions(true).
+		AddRetryConditions(func(_ *resty.Response, err error) bool { return err != nil })
+	if query != nil {
+		req.SetQueryParamsFromValues(query)
+	}
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		req.SetBody(data).SetContentType("application/json")
+	}
+
+	resp, err := req.Execute(method, c.baseURL+path)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", method, path, err)
+	}
+	if resp.IsError() {
+		return decodeError(resp)
+	}
+	if out == nil || len(resp.Bytes()) == 0 {
+		return nil
+	}
+	return json.Unmarshal(resp.Bytes(), out)
+}
+
+func decodeError(resp *resty.Response) error {
+	e := api.ErrResponse{}
+	if err := json.Unmarshal(resp.Bytes(), &e); err != nil || e.Message == "" {
+		e.Message = strings.TrimSpace(resp.String())
+		if e.Message == "" {
+			e.Message = http.StatusText(resp.StatusCode())
+		}
+	}
+	if e.HTTPStatusCode == 0 {
+		e.HTTPStatusCode = resp.StatusCode()
+	}
+	e.Message = strings.TrimSpace(e.Message)
+	return &e
+}
@@ -0,0 +1,828 @@
+// Command maestroctl is a command-line client for the maestro manager API.
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/go-connections/nat"
+	"github.com/docker/go-units"
+	"github.com/google/uuid"
+	"github.com/nduyhai/maestro/client"
+	"github.com/nduyhai/maestro/internal/config"
+	"github.com/nduyhai/maestro/internal/task"
+	"gopkg.in/yaml.v3"
+)
+
+const usage = `Usage: maestroctl <command> [flags]
+
+Commands:
+  run       submit a task from flags or a YAML/JSON file
+  ps        list tasks
+  stop      stop a task
+  inspect   show a task in detail
+  nodes     list worker nodes
+  taint     add or remove taints of a node
+  cordon    stop placing tasks on a node
+  uncordon  place tasks on a node again
+  drain     cordon a node and move its tasks elsewhere
+  events    list task events
+  group     submit tasks that start together or not at all
+  groups    list task groups
+  explain   show where a task would be scheduled and why
+
+Common flags:
+  -s, -server   manager URL (env MAESTRO_MANAGER_URL, default http://localhost:8080)
+  -o, -output   output format: json, yaml or wide
+
+Run 'maestroctl <command> -h' for the flags of a command.
+`
+
+type command struct {
+	fs     *flag.FlagSet
+	server string
+	output string
+	stdout io.Writer
+}
+
+func newCommand(name string, stdout io.Writer) *command {
+	c := &command{fs: flag.NewFlagSet(name, flag.ContinueOnError), stdout: stdout}
+	server := os.Getenv("MAESTRO_MANAGER_URL")
+	if server == "" {
+		server = "http://localhost:8080"
+	}
+	c.fs.StringVar(&c.server, "server", server, "manager URL (env MAESTRO_MANAGER_URL)")
+	c.fs.StringVar(&c.server, "s", server, "shorthand for -server")
+	c.fs.StringVar(&c.output, "output", "", "output format: json, yaml or wide")
+	c.fs.StringVar(&c.output, "o", "", "shorthand for -output")
+	return c
+}
+
+func (c *command) parse(args []string) error {
+	if err := c.fs.Parse(args); err != nil {
+		return err
+	}
+	return validOutput(c.output)
+}
+
+func (c *command) client() *client.Client {
+	return client.NewManager(c.server, client.WithTimeout(30*time.Second))
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	ctx := context.Background()
+	var err error
+	switch name, args := os.Args[1], os.Args[2:]; name {
+	case "run":
+		err = runCmd(ctx, args, os.Stdout)
+	case "ps":
+		err = psCmd(ctx, args, os.Stdout)
+	case "stop":
+		err = stopCmd(ctx, args, os.Stdout)
+	case "inspect":
+		err = inspectCmd(ctx, args, os.Stdout)
+	case "nodes":
+		err = nodesCmd(ctx, args, os.Stdout)
+	case "taint":
+		err = taintCmd(ctx, args, os.Stdout)
+	case "cordon", "uncordon":
+		err = cordonCmd(ctx, name, args, os.Stdout)
+	case "drain":
+		err = drainCmd(ctx, args, os.Stdout)
+	case "events":
+		err = eventsCmd(ctx, args, os.Stdout)
+	case "group":
+		err = groupCmd(ctx, args, os.Stdout)
+	case "groups":
+		err = groupsCmd(ctx, args, os.Stdout)
+	case "explain":
+		err = explainCmd(ctx, args, os.Stdout)
+	case "-h", "-help", "--help", "help":
+		fmt.Fprint(os.Stdout, usage)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", name, usage)
+		os.Exit(2)
+	}
+
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+type stringList []string
+
+func (l *stringList) String() string     { return strings.Join(*l, ",") }
+func (l *stringList) Set(v string) error { *l = append(*l, v); return nil }
+
+// taskFlags are the flags describing a task, shared by run and explain.
+type taskFlags struct {
+	file, name, image, memory, disk, restart, priority, profile string
+	cpu                                                         float64
+	env, ports, labels, nodeSelector                            stringList
+	affinity, antiAffinity, tolerations, spread                 stringList
+
+	healthCmd, healthHTTP                            string
+	healthTCP, healthRetries                         int
+	healthInterval, healthTimeout, healthStartPeriod time.Duration
+}
+
+func (f *taskFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.file, "f", "", "YAML or JSON file describing the task")
+	fs.StringVar(&f.name, "name", "", "task name")
+	fs.StringVar(&f.image, "image", "", "container image")
+	fs.Float64Var(&f.cpu, "cpu", 0, "CPU cores to reserve")
+	fs.StringVar(&f.memory, "memory", "", "memory limit, e.g. 256m")
+	fs.StringVar(&f.disk, "disk", "", "disk to reserve, e.g. 1g")
+	fs.StringVar(&f.restart, "restart", "", "restart policy: never, always or on-failure[:MAX_RESTARTS]")
+	fs.Var(&f.env, "env", "environment variable KEY=VALUE (repeatable)")
+	fs.Var(&f.ports, "port", "container port to expose, e.g. 80/tcp, or to publish on a host port, e.g. 8080:80/tcp (repeatable)")
+	fs.Var(&f.labels, "l", "task label key=value (repeatable)")
+	fs.Var(&f.nodeSelector, "node-selector", "node label key=value the task must run on (repeatable)")
+	fs.Var(&f.affinity, "affinity", "run next to a task matching this selector, e.g. app=cache (repeatable)")
+	fs.Var(&f.antiAffinity, "anti-affinity", "avoid nodes running a task matching this selector, e.g. app=db (repeatable)")
+	fs.StringVar(&f.priority, "priority", "", "priority class (low, normal, high, critical) or number")
+	fs.Var(&f.spread, "spread", "spread across failure domains, as key[:maxSkew[:selector]], e.g. zone:1 (repeatable)")
+	fs.StringVar(&f.profile, "profile", "", "scheduler profile placing the task (default: the manager's)")
+	fs.Var(&f.tolerations, "toleration", "tolerate a taint, e.g. dedicated=batch:NoSchedule or dedicated (repeatable)")
+	fs.StringVar(&f.healthCmd, "health-cmd", "", "health check command, run with sh -c in the container")
+	fs.StringVar(&f.healthHTTP, "health-http", "", "health check GET of a container port and path, e.g. 8080/healthz")
+	fs.IntVar(&f.healthTCP, "health-tcp", 0, "health check connecting to a container port")
+	fs.DurationVar(&f.healthInterval, "health-interval", 0, "time between health checks (default 10s)")
+	fs.DurationVar(&f.healthTimeout, "health-timeout", 0, "time a health check may take (default 5s)")
+	fs.IntVar(&f.healthRetries, "health-retries", 0, "failed health checks in a row that make the task unhealthy (default 3)")
+	fs.DurationVar(&f.healthStartPeriod, "health-start-period", 0, "time after the task starts during which failed health checks are not counted")
+}
+
+// healthCheck builds the health check described by the flags, or returns nil
+// when none is given.
+func (f *taskFlags) healthCheck() (*task.HealthCheck, error) {
+	if f.healthCmd == "" && f.healthHTTP == "" && f.healthTCP == 0 {
+		return nil, nil
+	}
+	h := &task.HealthCheck{
+		Port:             f.healthTCP,
+		TCP:              f.healthTCP != 0,
+		Interval:         f.healthInterval,
+		Timeout:          f.healthTimeout,
+		FailureThreshold: f.healthRetries,
+		StartPeriod:      f.healthStartPeriod,
+	}
+	if f.healthCmd != "" {
+		h.Exec = []string{"sh", "-c", f.healthCmd}
+	}
+	if f.healthHTTP != "" {
+		port, path, _ := strings.Cut(f.healthHTTP, "/")
+		p, err := strconv.Atoi(port)
+		if err != nil {
+			return nil, fmt.Errorf("invalid -health-http %q: want PORT[/PATH]", f.healthHTTP)
+		}
+		h.HTTP, h.Port, h.Path = true, p, "/"+path
+	}
+	return h, h.Validate()
+}
+
+// task builds the task described by the file, if any, and the other flags.
+func (f *taskFlags) task() (task.Task, error) {
+	var t task.Task
+	if f.file != "" {
+		if err := readSpecFile(f.file, &t); err != nil {
+			return t, err
+		}
+	}
+	if f.name != "" {
+		t.Name = f.name
+	}
+	if f.image != "" {
+		t.Image = f.image
+	}
+	if f.cpu != 0 {
+		t.CPU = f.cpu
+	}
+	if f.memory != "" {
+		v, err := units.RAMInBytes(f.memory)
+		if err != nil {
+			return t, fmt.Errorf("invalid -memory: %w", err)
+		}
+		t.Memory = v
+	}
+	if f.disk != "" {
+		v, err := units.RAMInBytes(f.disk)
+		if err != nil {
+			return t, fmt.Errorf("invalid -disk: %w", err)
+		}
+		t.Disk = v
+	}
+	if f.restart != "" {
+		p, limit, err := task.ParseRestartPolicy(f.restart)
+		if err != nil {
+			return t, fmt.Errorf("invalid -restart: %w", err)
+		}
+		t.RestartPolicy, t.MaxRestarts = p, limit
+	}
+	t.Env = append(t.Env, f.env...)
+	if len(f.ports) > 0 {
+		exposed, bindings, err := nat.ParsePortSpecs(f.ports)
+		if err != nil {
+			return t, fmt.Errorf("invalid -port: %w", err)
+		}
+		if t.ExposedPorts == nil {
+			t.ExposedPorts = nat.PortSet{}
+		}
+		for p := range exposed {
+			t.ExposedPorts[p] = struct{}{}
+		}
+		for p, b := range bindings {
+			if len(b) == 0 || b[0].HostPort == "" {
+				continue
+			}
+			if t.BindingPorts == nil {
+				t.BindingPorts = make(map[string]string)
+			}
+			t.BindingPorts[string(p)] = b[0].HostPort
+		}
+	}
+	if err := mergeLabels(&t.Labels, f.labels); err != nil {
+		return t, fmt.Errorf("invalid -l: %w", err)
+	}
+	if err := mergeLabels(&t.NodeSelector, f.nodeSelector); err != nil {
+		return t, fmt.Errorf("invalid -node-selector: %w", err)
+	}
+	for _, v := range f.affinity {
+		sel, err := task.ParseSelector(v)
+		if err != nil {
+			return t, fmt.Errorf("invalid -affinity: %w", err)
+		}
+		t.Affinity = append(t.Affinity, sel)
+	}
+	for _, v := range f.antiAffinity {
+		sel, err := task.ParseSelector(v)
+		if err != nil {
+			return t, fmt.Errorf("invalid -anti-affinity: %w", err)
+		}
+		t.AntiAffinity = append(t.AntiAffinity, sel)
+	}
+	if f.priority != "" {
+		p, err := task.ParsePriority(f.priority)
+		if err != nil {
+			return t, fmt.Errorf("invalid -priority: %w", err)
+		}
+		t.Priority = p
+	}
+	for _, v := range f.spread {
+		c, err := task.ParseTopologySpread(v)
+		if err != nil {
+			return t, fmt.Errorf("invalid -spread: %w", err)
+		}
+		t.TopologySpread = append(t.TopologySpread, c)
+	}
+	if f.profile != "" {
+		t.SchedulerProfile = f.profile
+	}
+	for _, v := range f.tolerations {
+		tol, err := task.ParseToleration(v)
+		if err != nil {
+			return t, fmt.Errorf("invalid -toleration: %w", err)
+		}
+		t.Tolerations = append(t.Tolerations, tol)
+	}
+	h, err := f.healthCheck()
+	if err != nil {
+		return t, err
+	}
+	if h != nil {
+		t.HealthCheck = h
+	}
+	return t, nil
+}
+
+func runCmd(ctx context.Context, args []string, stdout io.Writer) error {
+	c := newCommand("run", stdout)
+	var f taskFlags
+	f.register(c.fs)
+	c.fs.Usage = func() {
+		fmt.Fprintln(c.fs.Output(), "Usage: maestroctl run [flags] [-- command...]")
+		c.fs.PrintDefaults()
+	}
+	if err := c.parse(args); err != nil {
+		return err
+	}
+
+	t, err := f.task()
+	if err != nil {
+		return err
+	}
+	if c.fs.NArg() > 0 {
+		t.Cmd = c.fs.Args()
+	}
+
+	if t.Image == "" {
+		return errors.New("an image is required (-image or -f)")
+	}
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	if t.Name == "" {
+		t.Name = "task-" + t.ID.String()[:8]
+	}
+	t.State = task.Scheduled
+
+	te := task.Event{
+		ID:        uuid.New(),
+		State:     task.Scheduled,
+		Timestamp: time.Now().UTC(),
+		Task:      t,
+	}
+	submitted, err := c.client().SubmitTask(ctx, te)
+	if err != nil {
+		return err
+	}
+	if c.output == outputJSON || c.output == outputYAML {
+		return printStructured(stdout, c.output, submitted)
+	}
+	_, err = fmt.Fprintln(stdout, submitted.ID)
+	return err
+}
+
+func groupCmd(ctx context.Context, args []string, stdout io.Writer) error {
+	c := newCommand("group", stdout)
+	file := c.fs.String("f", "", "YAML or JSON file with the name and tasks of the group")
+	name := c.fs.String("name", "", "group name")
+	timeout := c.fs.Duration("timeout", 0, "fail the group if it cannot be placed within this time (0 waits forever)")
+	c.fs.Usage = func() {
+		fmt.Fprintln(c.fs.Output(), "Usage: maestroctl group -f FILE [flags]")
+		c.fs.PrintDefaults()
+	}
+	if err := c.parse(args); err != nil {
+		return err
+	}
+	if *file == "" {
+		return errors.New("a group file is required (-f)")
+	}
+
+	var g task.Group
+	if err := readSpecFile(*file, &g); err != nil {
+		return err
+	}
+	if *name != "" {
+		g.Name = *name
+	}
+	if *timeout != 0 {
+		g.Timeout = *timeout
+	}
+	for i, t := range g.Tasks {
+		if t.Image == "" {
+			return fmt.Errorf("task %d of the group has no image", i)
+		}
+	}
+
+	submitted, err := c.client().SubmitGroup(ctx, g)
+	if err != nil {
+		return err
+	}
+	if c.output == outputJSON || c.output == outputYAML {
+		return printStructured(stdout, c.output, submitted)
+	}
+	_, err = fmt.Fprintln(stdout, submitted.ID)
+	return err
+}
+
+func groupsCmd(ctx context.Context, args []string, stdout io.Writer) error {
+	c := newCommand("groups", stdout)
+	if err := c.parse(args); err != nil {
+		return err
+	}
+	groups, err := c.client().ListGroups(ctx)
+	if err != nil {
+		return err
+	}
+	if c.output == outputJSON || c.output == outputYAML {
+		return printStructured(stdout, c.output, groups)
+	}
+
+	slices.SortFunc(groups, func(a, b *task.Group) int { return cmp.Compare(a.Name, b.Name) })
+	tbl := newTable(stdout, "ID", "NAME", "TASKS", "PLACED", "DEADLINE")
+	for _, g := range groups {
+		deadline := "-"
+		if !g.Deadline.IsZero() {
+			deadline = g.Deadline.Local().Format(time.DateTime)
+		}
+		tbl.row(shortID(g.ID.String()), g.Name, strconv.Itoa(len(g.Tasks)), strconv.FormatBool(g.Placed), deadline)
+	}
+	return tbl.flush()
+}
+
+func explainCmd(ctx context.Context, args []string, stdout io.Writer) error {
+	c := newCommand("explain", stdout)
+	var f taskFlags
+	f.register(c.fs)
+	c.fs.Usage = func() {
+		fmt.Fprintln(c.fs.Output(), "Usage: maestroctl explain [flags] [TASK_ID]")
+		fmt.Fprintln(c.fs.Output(), "Explains where an existing task, or the one described by the flags, would be placed.")
+		c.fs.PrintDefaults()
+	}
+	if err := c.parse(args); err != nil {
+		return err
+	}
+
+	cl := c.client()
+	var t task.Task
+	switch c.fs.NArg() {
+	case 0:
+		var err error
+		if t, err = f.task(); err != nil {
+			return err
+		}
+	case 1:
+		id, err := uuid.Parse(c.fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		existing, err := cl.GetTask(ctx, id)
+		if err != nil {
+			return err
+		}
+		t = *existing
+	default:
+		return errors.New("explain expects at most one task ID")
+	}
+
+	e, err := cl.Explain(ctx, t)
+	if err != nil {
+		return err
+	}
+	if c.output == outputJSON || c.output == outputYAML {
+		return printStructured(stdout, c.output, e)
+	}
+
+	tbl := newTable(stdout, "NODE", "FEASIBLE", "SCORE", "PREDICATE", "REASON")
+	for _, n := range e.Nodes {
+		score := "-"
+		if n.Score != nil {
+			score = strconv.FormatFloat(*n.Score, 'f', 3, 64)
+		}
+		name := n.Node
+		if name == e.Selected {
+			name += " *"
+		}
+		tbl.row(name, strconv.FormatBool(n.Feasible), score, valueOrDash(n.Predicate), valueOrDash(n.Reason))
+	}
+	if err := tbl.flush(); err != nil {
+		return err
+	}
+	switch {
+	case e.Selected != "":
+		_, err = fmt.Fprintf(stdout, "\nselected: %s\n", e.Selected)
+	case e.Preemption != nil:
+		victims := make([]string, 0, len(e.Preemption.Victims))
+		for _, v := range e.Preemption.Victims {
+			victims = append(victims, fmt.Sprintf("%s (priority %d)", v.Name, v.Priority))
+		}
+		_, err = fmt.Fprintf(stdout, "\nno node fits; would preempt on %s: %s\n", e.Preemption.Node, strings.Join(victims, ", "))
+	default:
+		_, err = fmt.Fprintln(stdout, "\nno node fits")
+	}
+	return err
+}
+
+// mergeLabels adds the key=value pairs of values to *labels.
+func mergeLabels(labels *map[string]string, values []string) error {
+	for _, v := range values {
+		parsed, err := config.ParseLabels(v)
+		if err != nil {
+			return err
+		}
+		if *labels == nil {
+			*labels = make(map[string]string)
+		}
+		maps.Copy(*labels, parsed)
+	}
+	return nil
+}
+
+// readSpecFile decodes a task or group spec. YAML is converted to JSON first
+// so that keys are matched against fields the same way the API matches them.
+func readSpecFile(path string, v any) error {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return err
+	}
+	var doc any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("parse %s: %w", path, err)
+	}
+	data, err = json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("parse %s: %w", path, err)
+	}
+	d := json.NewDecoder(strings.NewReader(string(data)))
+	d.DisallowUnknownFields()
+	if err := d.Decode(v); err != nil {
+		return fmt.Errorf("parse %s: %w", path, err)
+	}
+	return nil
+}
+
+func psCmd(ctx context.Context, args []string, stdout io.Writer) error {
+	c := newCommand("ps", stdout)
+	all := c.fs.Bool("a", false, "show completed and failed tasks too")
+	if err := c.parse(args); err != nil {
+		return err
+	}
+
+	tasks, err := c.client().ListTasks(ctx)
+	if err != nil {
+		return err
+	}
+	if !*all {
+		tasks = slices.DeleteFunc(tasks, func(t *task.Task) bool {
+			return t.State == task.Completed || t.State == task.Failed
+		})
+	}
+	slices.SortFunc(tasks, func(a, b *task.Task) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	if c.output == outputJSON || c.output == outputYAML {
+		return printStructured(stdout, c.output, tasks)
+	}
+
+	now := time.Now()
+	headers := []string{"ID", "NAME", "STATE", "RESTARTS", "NODE", "PORTS", "AGE"}
+	if c.output == outputWide {
+		headers = append(headers, "IMAGE", "CPU", "MEMORY", "PRIORITY", "GROUP", "CONTAINER")
+	}
+	tbl := newTable(stdout, headers...)
+	for _, t := range tasks {
+		row := []string{
+			shortID(t.ID.String()),
+			t.Name,
+			formatState(t),
+			strconv.Itoa(t.RestartCount),
+			valueOrDash(t.Node),
+			formatPorts(t.HostPorts, t.ExposedPorts),
+			formatAge(t.StartTime, now),
+		}
+		if c.output == outputWide {
+			row = append(row,
+				t.Image,
+				strconv.FormatFloat(t.CPU, 'f', -1, 64),
+				units.BytesSize(float64(t.Memory)),
+				strconv.Itoa(t.Priority),
+				valueOrDash(formatGroup(t.Group)),
+				valueOrDash(shortID(t.ContainerID)),
+			)
+		}
+		tbl.row(row...)
+	}
+	return tbl.flush()
+}
+
+func parseTaskID(c *command) (uuid.UUID, error) {
+	if c.fs.NArg() != 1 {
+		return uuid.Nil, fmt.Errorf("%s expects exactly one task ID", c.fs.Name())
+	}
+	return uuid.Parse(c.fs.Arg(0))
+}
+
+func stopCmd(ctx context.Context, args []string, stdout io.Writer) error {
+	c := newCommand("stop", stdout)
+	if err := c.parse(args); err != nil {
+		return err
+	}
+	id, err := parseTaskID(c)
+	if err != nil {
+		return err
+	}
+	if err := c.client().StopTask(ctx, id); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(stdout, id)
+	return err
+}
+
+func inspectCmd(ctx context.Context, args []string, stdout io.Writer) error {
+	c := newCommand("inspect", stdout)
+	if err := c.parse(args); err != nil {
+		return err
+	}
+	id, err := parseTaskID(c)
+	if err != nil {
+		return err
+	}
+	t, err := c.client().GetTask(ctx, id)
+	if err != nil {
+		return err
+	}
+	format := c.output
+	if format != outputYAML {
+		format = outputJSON
+	}
+	return printStructured(stdout, format, t)
+}
+
+func nodesCmd(ctx context.Context, args []string, stdout io.Writer) error {
+	c := newCommand("nodes", stdout)
+	if err := c.parse(args); err != nil {
+		return err
+	}
+	nodes, err := c.client().ListNodes(ctx)
+	if err != nil {
+		return err
+	}
+	if c.output == outputJSON || c.output == outputYAML {
+		return printStructured(stdout, c.output, nodes)
+	}
+
+	headers := []string{"NAME", "STATUS", "CPU", "MEMORY", "DISK", "TASKS"}
+	if c.output == outputWide {
+		headers = append(headers, "API", "ROLE", "ZONE", "RACK", "LABELS", "TAINTS", "HOST PORTS", "LAST SEEN")
+	}
+	tbl := newTable(stdout, headers...)
+	for _, n := range nodes {
+		row := []string{
+			n.Name,
+			formatNodeStatus(n),
+			fmt.Sprintf("%s/%d", strconv.FormatFloat(n.CPUAllocated, 'f', -1, 64), n.Cores),
+			fmt.Sprintf("%s/%s", units.BytesSize(float64(n.MemoryAllocated)), units.BytesSize(float64(n.Memory))),
+			fmt.Sprintf("%s/%s", units.BytesSize(float64(n.DiskAllocated)), units.BytesSize(float64(n.Disk))),
+			strconv.Itoa(n.TaskCount),
+		}
+		if c.output == outputWide {
+			row = append(row, n.IP, valueOrDash(n.Role), valueOrDash(n.Zone), valueOrDash(n.Rack), valueOrDash(formatLabels(n.Labels)), valueOrDash(formatTaints(n.Taints)), valueOrDash(formatHostPorts(n.HostPorts)), formatAge(n.LastSeen, time.Now()))
+		}
+		tbl.row(row...)
+	}
+	return tbl.flush()
+}
+
+func taintCmd(ctx context.Context, args []string, stdout io.Writer) error {
+	c := newCommand("taint", stdout)
+	c.fs.Usage = func() {
+		fmt.Fprintln(c.fs.Output(), "Usage: maestroctl taint [flags] NODE key[=value]:Effect... | key-...")
+		c.fs.PrintDefaults()
+	}
+	if err := c.parse(args); err != nil {
+		return err
+	}
+	if c.fs.NArg() < 2 {
+		c.fs.Usage()
+		return errors.New("a node and at least one taint are required")
+	}
+	nodeName := c.fs.Arg(0)
+	cl := c.client()
+	for _, arg := range c.fs.Args()[1:] {
+		if key, ok := strings.CutSuffix(arg, "-"); ok {
+			if err := cl.RemoveTaint(ctx, nodeName, key); err != nil {
+				return err
+			}
+			continue
+		}
+		t, err := task.ParseTaint(arg)
+		if err != nil {
+			return err
+		}
+		if err := cl.AddTaint(ctx, nodeName, t); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintln(stdout, nodeName)
+	return err
+}
+
+// cordonCmd runs cordon or uncordon, as given by name.
+func cordonCmd(ctx context.Context, name string, args []string, stdout io.Writer) error {
+	c := newCommand(name, stdout)
+	c.fs.Usage = func() {
+		fmt.Fprintf(c.fs.Output(), "Usage: maestroctl %s [flags] NODE\n", name)
+		c.fs.PrintDefaults()
+	}
+	if err := c.parse(args); err != nil {
+		return err
+	}
+	if c.fs.NArg() != 1 {
+		c.fs.Usage()
+		return errors.New("a node is required")
+	}
+	nodeName := c.fs.Arg(0)
+	cordon := c.client().Cordon
+	if name == "uncordon" {
+		cordon = c.client().Uncordon
+	}
+	if err := cordon(ctx, nodeName); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(stdout, nodeName)
+	return err
+}
+
+func drainCmd(ctx context.Context, args []string, stdout io.Writer) error {
+	c := newCommand("drain", stdout)
+	maxUnavailable := c.fs.Int("max-unavailable", 1, "number of tasks moved off the node at a time")
+	c.fs.Usage = func() {
+		fmt.Fprintln(c.fs.Output(), "Usage: maestroctl drain [flags] NODE")
+		c.fs.PrintDefaults()
+	}
+	if err := c.parse(args); err != nil {
+		return err
+	}
+	if c.fs.NArg() != 1 {
+		c.fs.Usage()
+		return errors.New("a node is required")
+	}
+	if *maxUnavailable < 1 {
+		return fmt.Errorf("-max-unavailable must be at least 1, got %d", *maxUnavailable)
+	}
+	nodeName := c.fs.Arg(0)
+	if err := c.client().Drain(ctx, nodeName, *maxUnavailable); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(stdout, nodeName)
+	return err
+}
+
+func eventsCmd(ctx context.Context, args []string, stdout io.Writer) error {
+	c := newCommand("events", stdout)
+	taskFlag := c.fs.String("task", "", "only show events of this task ID")
+	watch := c.fs.Bool("w", false, "keep watching for new events (json output prints one event per line)")
+	if err := c.parse(args); err != nil {
+		return err
+	}
+	var taskID uuid.UUID
+	if *taskFlag != "" {
+		id, err := uuid.Parse(*taskFlag)
+		if err != nil {
+			return fmt.Errorf("invalid -task: %w", err)
+		}
+		taskID = id
+	}
+
+	if *watch {
+		ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+		defer stop()
+		enc := json.NewEncoder(stdout)
+		err := c.client().WatchEvents(ctx, taskID, func(e *task.Event) {
+			if c.output == outputJSON {
+				_ = enc.Encode(e)
+				return
+			}
+			_, _ = fmt.Fprintln(stdout, strings.Join(eventRow(e, c.output), "  "))
+		})
+		if errors.Is(err, context.Canceled) {
+			return nil
+		}
+		return err
+	}
+
+	events, err := c.client().ListEvents(ctx, taskID, time.Time{})
+	if err != nil {
+		return err
+	}
+	if c.output == outputJSON || c.output == outputYAML {
+		return printStructured(stdout, c.output, events)
+	}
+
+	headers := []string{"TIME", "TASK", "NAME", "STATE"}
+	if c.output == outputWide {
+		headers = append(headers, "EVENT", "NODE")
+	}
+	headers = append(headers, "MESSAGE")
+	tbl := newTable(stdout, headers...)
+	for _, e := range events {
+		tbl.row(eventRow(e, c.output)...)
+	}
+	return tbl.flush()
+}
+
+func eventRow(e *task.Event, output string) []string {
+	row := []string{
+		e.Timestamp.Local().Format(time.DateTime),
+		shortID(e.Task.ID.String()),
+		e.Task.Name,
+		e.State.String(),
+	}
+	if output == outputWide {
+		row = append(row, e.ID.String(), valueOrDash(e.Task.Node))
+	}
+	return append(row, valueOrDash(e.Message))
+}
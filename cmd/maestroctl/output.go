@@ -0,0 +1,177 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/docker/go-connections/nat"
+	"github.com/google/uuid"
+	"github.com/nduyhai/maestro/internal/node"
+	"github.com/nduyhai/maestro/internal/task"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	outputTable = ""
+	outputWide  = "wide"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+)
+
+func validOutput(format string) error {
+	switch format {
+	case outputTable, outputWide, outputJSON, outputYAML:
+		return nil
+	default:
+		return fmt.Errorf("unknown output format %q (want json, yaml or wide)", format)
+	}
+}
+
+// printStructured writes v as JSON or YAML. YAML is produced from the JSON
+// encoding so that both formats use the same field names as the API.
+func printStructured(w io.Writer, format string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if format == outputJSON {
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	}
+
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+type table struct {
+	tw *tabwriter.Writer
+}
+
+func newTable(w io.Writer, headers ...string) *table {
+	t := &table{tw: tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)}
+	t.row(headers...)
+	return t
+}
+
+func (t *table) row(columns ...string) {
+	_, _ = fmt.Fprintln(t.tw, strings.Join(columns, "\t"))
+}
+
+func (t *table) flush() error {
+	return t.tw.Flush()
+}
+
+func formatAge(since time.Time, now time.Time) string {
+	if since.IsZero() {
+		return "-"
+	}
+	d := now.Sub(since)
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+}
+
+func formatPorts(ports nat.PortMap, exposed nat.PortSet) string {
+	var out []string
+	for port, bindings := range ports {
+		for _, b := range bindings {
+			out = append(out, fmt.Sprintf("%s:%s->%s", b.HostIP, b.HostPort, port))
+		}
+	}
+	if len(out) == 0 {
+		for port := range exposed {
+			out = append(out, string(port))
+		}
+	}
+	if len(out) == 0 {
+		return "-"
+	}
+	slices.Sort(out)
+	return strings.Join(out, ",")
+}
+
+func formatHostPorts(ports []nat.Port) string {
+	out := make([]string, 0, len(ports))
+	for _, p := range ports {
+		out = append(out, string(p))
+	}
+	return strings.Join(out, ",")
+}
+
+// formatState shows the health of a running task next to its state.
+func formatState(t *task.Task) string {
+	if t.State == task.Running && t.Health != "" {
+		return fmt.Sprintf("%s (%s)", t.State, t.Health)
+	}
+	return t.State.String()
+}
+
+// formatNodeStatus shows whether a node is cordoned or draining next to its
+// status, e.g. Ready,Cordoned.
+func formatNodeStatus(n *node.Node) string {
+	switch {
+	case n.Draining:
+		return string(n.Status) + ",Draining"
+	case n.Cordoned:
+		return string(n.Status) + ",Cordoned"
+	}
+	return string(n.Status)
+}
+
+func formatGroup(id uuid.UUID) string {
+	if id == uuid.Nil {
+		return ""
+	}
+	return shortID(id.String())
+}
+
+func formatLabels(labels map[string]string) string {
+	out := make([]string, 0, len(labels))
+	for _, k := range slices.Sorted(maps.Keys(labels)) {
+		out = append(out, k+"="+labels[k])
+	}
+	return strings.Join(out, ",")
+}
+
+func formatTaints(taints []task.Taint) string {
+	out := make([]string, 0, len(taints))
+	for _, t := range taints {
+		out = append(out, t.String())
+	}
+	return strings.Join(out, ",")
+}
+
+func valueOrDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func shortID(s string) string {
+	if len(s) > 12 {
+		return s[:12]
+	}
+	return s
+}
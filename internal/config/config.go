@@ -0,0 +1,361 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nduyhai/maestro/internal/scheduler"
+	"github.com/nduyhai/maestro/internal/task"
+	"gopkg.in/yaml.v3"
+)
+
+type Manager struct {
+	Addr              string              `yaml:"addr"`
+	DataDir           string              `yaml:"dataDir"`
+	Workers           []string            `yaml:"workers"`
+	LogLevel          string              `yaml:"logLevel"`
+	Scheduler         string              `yaml:"scheduler"`
+	Profiles          []scheduler.Profile `yaml:"profiles"`
+	Preemption        bool                `yaml:"preemption"`
+	PriorityAging     time.Duration       `yaml:"priorityAging"`
+	NodeTimeout       time.Duration       `yaml:"nodeTimeout"`
+	LostTimeout       time.Duration       `yaml:"lostTimeout"`
+	RestartBackoff    time.Duration       `yaml:"restartBackoff"`
+	MaxRestartBackoff time.Duration       `yaml:"maxRestartBackoff"`
+	DispatchInterval  time.Duration       `yaml:"dispatchInterval"`
+	ReconcileInterval time.Duration       `yaml:"reconcileInterval"`
+	HealthInterval    time.Duration       `yaml:"healthInterval"`
+}
+
+type Worker struct {
+	Name                string            `yaml:"name"`
+	Zone                string            `yaml:"zone"`
+	Rack                string            `yaml:"rack"`
+	Addr                string            `yaml:"addr"`
+	DataDir             string            `yaml:"dataDir"`
+	ManagerURL          string            `yaml:"managerURL"`
+	AdvertiseURL        string            `yaml:"advertiseURL"`
+	Runtime             string            `yaml:"runtime"`
+	LogLevel            string            `yaml:"logLevel"`
+	Concurrency         int               `yaml:"concurrency"`
+	UpdateInterval      time.Duration     `yaml:"updateInterval"`
+	HeartbeatInterval   time.Duration     `yaml:"heartbeatInterval"`
+	HealthCheckInterval time.Duration     `yaml:"healthCheckInterval"`
+	Labels              map[string]string `yaml:"labels"`
+	Taints              []string          `yaml:"taints"`
+}
+
+func DefaultManager() Manager {
+	return Manager{
+		Addr:              ":8080",
+		DataDir:           ".",
+		LogLevel:          "info",
+		Scheduler:         "roundrobin",
+		PriorityAging:     time.Second,
+		NodeTimeout:       30 * time.Second,
+		LostTimeout:       time.Minute,
+		RestartBackoff:    time.Second,
+		MaxRestartBackoff: 5 * time.Minute,
+		DispatchInterval:  5 * time.Second,
+		ReconcileInterval: 15 * time.Second,
+		HealthInterval:    10 * time.Second,
+	}
+}
+
+func DefaultWorker() Worker {
+	name, _ := os.Hostname()
+	return Worker{
+		Name:                name,
+		Addr:                ":8081",
+		DataDir:             ".",
+		ManagerURL:          "http://localhost:8080",
+		Runtime:             "docker",
+		LogLevel:            "info",
+		Concurrency:         4,
+		UpdateInterval:      15 * time.Second,
+		HeartbeatInterval:   5 * time.Second,
+		HealthCheckInterval: time.Second,
+	}
+}
+
+// LoadManager builds the manager configuration from, in increasing order of
+// precedence, defaults, the config file, MAESTRO_* environment variables and flags.
+func LoadManager(args []string) (Manager, error) {
+	cfg := DefaultManager()
+	l := newLoader("manager")
+	l.string(&cfg.Addr, "addr", "MAESTRO_ADDR", "address the manager API listens on")
+	l.string(&cfg.DataDir, "data-dir", "MAESTRO_DATA_DIR", "directory holding the manager database")
+	l.list(&cfg.Workers, "workers", "MAESTRO_WORKERS", "comma separated list of static worker addresses, polled by the manager instead of registering")
+	l.string(&cfg.LogLevel, "log-level", "MAESTRO_LOG_LEVEL", "log level (debug, info, warn, error)")
+	l.string(&cfg.Scheduler, "scheduler", "MAESTRO_SCHEDULER", "default scheduler profile (roundrobin, leastloaded, binpack, epvm or one from the config file)")
+	l.bool(&cfg.Preemption, "preemption", "MAESTRO_PREEMPTION", "let tasks that fit nowhere stop tasks of a lower priority")
+	l.duration(&cfg.PriorityAging, "priority-aging", "MAESTRO_PRIORITY_AGING", "time a pending task waits to gain one point of priority (0 disables aging)")
+	l.duration(&cfg.NodeTimeout, "node-timeout", "MAESTRO_NODE_TIMEOUT", "time without a heartbeat after which a registered worker is not ready")
+	l.duration(&cfg.LostTimeout, "lost-timeout", "MAESTRO_LOST_TIMEOUT", "time a worker stays unreachable before its tasks are rescheduled (0 disables it)")
+	l.duration(&cfg.RestartBackoff, "restart-backoff", "MAESTRO_RESTART_BACKOFF", "delay before the first restart of a task, doubled for each further restart")
+	l.duration(&cfg.MaxRestartBackoff, "max-restart-backoff", "MAESTRO_MAX_RESTART_BACKOFF", "longest delay before restarting a task")
+	l.duration(&cfg.DispatchInterval, "dispatch-interval", "MAESTRO_DISPATCH_INTERVAL", "how often pending tasks are dispatched to workers")
+	l.duration(&cfg.ReconcileInterval, "reconcile-interval", "MAESTRO_RECONCILE_INTERVAL", "how often task state is synced from workers")
+	l.duration(&cfg.HealthInterval, "health-interval", "MAESTRO_HEALTH_INTERVAL", "how often worker health is checked")
+	if err := l.load(args, &cfg); err != nil {
+		return Manager{}, err
+	}
+	if _, err := ParseLevel(cfg.LogLevel); err != nil {
+		return Manager{}, err
+	}
+	if _, err := scheduler.NewProfiles(cfg.Scheduler, cfg.Profiles); err != nil {
+		return Manager{}, err
+	}
+	if cfg.DispatchInterval <= 0 {
+		return Manager{}, fmt.Errorf("dispatch interval must be positive, got %s", cfg.DispatchInterval)
+	}
+	if cfg.ReconcileInterval <= 0 {
+		return Manager{}, fmt.Errorf("reconcile interval must be positive, got %s", cfg.ReconcileInterval)
+	}
+	if cfg.HealthInterval <= 0 {
+		return Manager{}, fmt.Errorf("health interval must be positive, got %s", cfg.HealthInterval)
+	}
+	if cfg.MaxRestartBackoff < cfg.RestartBackoff {
+		return Manager{}, fmt.Errorf("max restart backoff %s is below the restart backoff %s", cfg.MaxRestartBackoff, cfg.RestartBackoff)
+	}
+	return cfg, nil
+}
+
+// LoadWorker builds the worker configuration the same way as LoadManager.
+func LoadWorker(args []string) (Worker, error) {
+	cfg := DefaultWorker()
+	l := newLoader("worker")
+	l.string(&cfg.Name, "name", "MAESTRO_NAME", "name of the worker node")
+	l.string(&cfg.Zone, "zone", "MAESTRO_ZONE", "zone (failure domain) of the worker node")
+	l.string(&cfg.Rack, "rack", "MAESTRO_RACK", "rack of the worker node")
+	l.string(&cfg.Addr, "addr", "MAESTRO_ADDR", "address the worker API listens on")
+	l.string(&cfg.DataDir, "data-dir", "MAESTRO_DATA_DIR", "directory holding the worker database")
+	l.string(&cfg.ManagerURL, "manager-url", "MAESTRO_MANAGER_URL", "base URL of the manager API the worker registers with (empty to not register)")
+	l.string(&cfg.AdvertiseURL, "advertise-url", "MAESTRO_ADVERTISE_URL", "base URL the manager reaches the worker API at (default http://HOSTNAME:PORT)")
+	l.string(&cfg.Runtime, "runtime", "MAESTRO_RUNTIME", "container runtime (docker, fake)")
+	l.string(&cfg.LogLevel, "log-level", "MAESTRO_LOG_LEVEL", "log level (debug, info, warn, error)")
+	l.int(&cfg.Concurrency, "concurrency", "MAESTRO_CONCURRENCY", "number of task operations executed in parallel")
+	l.duration(&cfg.UpdateInterval, "update-interval", "MAESTRO_UPDATE_INTERVAL", "how often task state is synced from the runtime")
+	l.duration(&cfg.HeartbeatInterval, "heartbeat-interval", "MAESTRO_HEARTBEAT_INTERVAL", "how often the worker sends a heartbeat to the manager")
+	l.duration(&cfg.HealthCheckInterval, "health-check-interval", "MAESTRO_HEALTH_CHECK_INTERVAL", "how often due task health checks are run")
+	l.labels(&cfg.Labels, "labels", "MAESTRO_LABELS", "comma separated key=value labels of the worker node")
+	l.list(&cfg.Taints, "taints", "MAESTRO_TAINTS", "comma separated key=value:Effect taints of the worker node")
+	if err := l.load(args, &cfg); err != nil {
+		return Worker{}, err
+	}
+	if cfg.AdvertiseURL == "" {
+		host, _ := os.Hostname()
+		cfg.AdvertiseURL = "http://" + net.JoinHostPort(host, cfg.port())
+	}
+	if cfg.UpdateInterval <= 0 {
+		return Worker{}, fmt.Errorf("update interval must be positive, got %s", cfg.UpdateInterval)
+	}
+	if cfg.HeartbeatInterval <= 0 {
+		return Worker{}, fmt.Errorf("heartbeat interval must be positive, got %s", cfg.HeartbeatInterval)
+	}
+	if cfg.HealthCheckInterval <= 0 {
+		return Worker{}, fmt.Errorf("health check interval must be positive, got %s", cfg.HealthCheckInterval)
+	}
+	if cfg.Concurrency < 1 {
+		return Worker{}, fmt.Errorf("concurrency must be at least 1, got %d", cfg.Concurrency)
+	}
+	if _, err := ParseLevel(cfg.LogLevel); err != nil {
+		return Worker{}, err
+	}
+	if cfg.Runtime != "docker" && cfg.Runtime != "fake" {
+		return Worker{}, fmt.Errorf("unknown runtime %q", cfg.Runtime)
+	}
+	for _, t := range cfg.Taints {
+		if _, err := task.ParseTaint(t); err != nil {
+			return Worker{}, err
+		}
+	}
+	return cfg, nil
+}
+
+// port returns the port of Addr.
+func (w Worker) port() string {
+	if _, port, err := net.SplitHostPort(w.Addr); err == nil {
+		return port
+	}
+	return strings.TrimPrefix(w.Addr, ":")
+}
+
+func ParseLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(s)); err != nil {
+		return level, fmt.Errorf("invalid log level %q", s)
+	}
+	return level, nil
+}
+
+type field struct {
+	name string
+	env  string
+	raw  *string
+	set  func(string) error
+}
+
+// loader applies flags and environment variables on top of a config file.
+// Flag values are only recorded while parsing so that they can be applied last.
+type loader struct {
+	fs     *flag.FlagSet
+	config *string
+	fields []field
+}
+
+func newLoader(name string) *loader {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	return &loader{
+		fs:     fs,
+		config: fs.String("config", os.Getenv("MAESTRO_CONFIG"), "path to a YAML config file (env MAESTRO_CONFIG)"),
+	}
+}
+
+func (l *loader) string(p *string, name, env, usage string) {
+	raw := l.fs.String(name, *p, fmt.Sprintf("%s (env %s)", usage, env))
+	l.fields = append(l.fields, field{name: name, env: env, raw: raw, set: func(v string) error { *p = v; return nil }})
+}
+
+func (l *loader) list(p *[]string, name, env, usage string) {
+	raw := l.fs.String(name, strings.Join(*p, ","), fmt.Sprintf("%s (env %s)", usage, env))
+	l.fields = append(l.fields, field{name: name, env: env, raw: raw, set: func(v string) error { *p = splitList(v); return nil }})
+}
+
+func (l *loader) int(p *int, name, env, usage string) {
+	raw := l.fs.String(name, strconv.Itoa(*p), fmt.Sprintf("%s (env %s)", usage, env))
+	l.fields = append(l.fields, field{name: name, env: env, raw: raw, set: func(v string) error {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", name, err)
+		}
+		*p = n
+		return nil
+	}})
+}
+
+func (l *loader) bool(p *bool, name, env, usage string) {
+	raw := new(string)
+	*raw = strconv.FormatBool(*p)
+	l.fs.Var(boolString{raw}, name, fmt.Sprintf("%s (env %s)", usage, env))
+	l.fields = append(l.fields, field{name: name, env: env, raw: raw, set: func(v string) error {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", name, err)
+		}
+		*p = b
+		return nil
+	}})
+}
+
+// boolString records a flag value as a string but, like a bool flag, can be
+// given without a value.
+type boolString struct{ p *string }
+
+func (b boolString) String() string {
+	if b.p == nil {
+		return ""
+	}
+	return *b.p
+}
+
+func (b boolString) Set(v string) error { *b.p = v; return nil }
+func (b boolString) IsBoolFlag() bool   { return true }
+
+func (l *loader) duration(p *time.Duration, name, env, usage string) {
+	raw := l.fs.String(name, p.String(), fmt.Sprintf("%s (env %s)", usage, env))
+	l.fields = append(l.fields, field{name: name, env: env, raw: raw, set: func(v string) error {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", name, err)
+		}
+		*p = d
+		return nil
+	}})
+}
+
+func (l *loader) labels(p *map[string]string, name, env, usage string) {
+	raw := l.fs.String(name, joinLabels(*p), fmt.Sprintf("%s (env %s)", usage, env))
+	l.fields = append(l.fields, field{name: name, env: env, raw: raw, set: func(v string) error {
+		labels, err := ParseLabels(v)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", name, err)
+		}
+		*p = labels
+		return nil
+	}})
+}
+
+func (l *loader) load(args []string, cfg any) error {
+	if err := l.fs.Parse(args); err != nil {
+		return err
+	}
+	if l.fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %v", l.fs.Args())
+	}
+
+	if *l.config != "" {
+		data, err := os.ReadFile(*l.config)
+		if err != nil {
+			return fmt.Errorf("read config: %w", err)
+		}
+		if err := yaml.Unmarshal(data, cfg); err != nil {
+			return fmt.Errorf("parse config %s: %w", *l.config, err)
+		}
+	}
+
+	visited := make(map[string]bool)
+	l.fs.Visit(func(f *flag.Flag) { visited[f.Name] = true })
+	for _, f := range l.fields {
+		if v, ok := os.LookupEnv(f.env); ok {
+			if err := f.set(v); err != nil {
+				return err
+			}
+		}
+		if visited[f.name] {
+			if err := f.set(*f.raw); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// ParseLabels parses a comma separated list of key=value pairs.
+func ParseLabels(s string) (map[string]string, error) {
+	labels := make(map[string]string)
+	for _, item := range splitList(s) {
+		k, v, ok := strings.Cut(item, "=")
+		if k = strings.TrimSpace(k); !ok || k == "" {
+			return nil, fmt.Errorf("label %q is not in key=value form", item)
+		}
+		labels[k] = strings.TrimSpace(v)
+	}
+	return labels, nil
+}
+
+func joinLabels(labels map[string]string) string {
+	items := make([]string, 0, len(labels))
+	for _, k := range slices.Sorted(maps.Keys(labels)) {
+		items = append(items, k+"="+labels[k])
+	}
+	return strings.Join(items, ",")
+}
@@ -1,6 +1,25 @@
 package httpx
 
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
 type ErrResponse struct {
 	HTTPStatusCode int
 	Message        string
 }
+
+func WriteError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(ErrResponse{
+		HTTPStatusCode: status,
+		Message:        msg,
+	})
+}
+
+func (e *ErrResponse) Error() string {
+	return fmt.Sprintf("%d %s: %s", e.HTTPStatusCode, http.StatusText(e.HTTPStatusCode), e.Message)
+}
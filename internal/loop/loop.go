@@ -0,0 +1,98 @@
+// Package loop runs supervised background loops.
+package loop
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/go-chi/httplog/v2"
+)
+
+// Group runs named loops until it is stopped.
+type Group struct {
+	Logger *httplog.Logger
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewGroup(logger *httplog.Logger) *Group {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Group{Logger: logger, ctx: ctx, cancel: cancel}
+}
+
+// Every calls fn every interval, and additionally whenever trigger fires if it
+// is not nil. A panic in fn is logged and the loop carries on with the next run.
+// It fails without starting the loop unless interval is positive.
+func (g *Group) Every(name string, interval time.Duration, trigger <-chan struct{}, fn func(ctx context.Context)) error {
+	if interval <= 0 {
+		return fmt.Errorf("loop %s: interval must be positive, got %s", name, interval)
+	}
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		g.Logger.Info("Starting loop", slog.String("loop", name), slog.Duration("interval", interval))
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			g.run(name, fn)
+			select {
+			case <-g.ctx.Done():
+				g.Logger.Info("Stopped loop", slog.String("loop", name))
+				return
+			case <-ticker.C:
+			case <-trigger:
+			}
+		}
+	}()
+	return nil
+}
+
+// Go runs fn in the group until the group stops. If fn panics or returns
+// while the group is still running it is restarted after a second.
+func (g *Group) Go(name string, fn func(ctx context.Context)) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		for {
+			g.run(name, fn)
+			select {
+			case <-g.ctx.Done():
+				return
+			case <-time.After(time.Second):
+				g.Logger.Info("Restarting loop", slog.String("loop", name))
+			}
+		}
+	}()
+}
+
+// Stop cancels every loop and waits for them to return or for ctx to be done.
+func (g *Group) Stop(ctx context.Context) error {
+	g.cancel()
+	done := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("waiting for loops to stop: %w", ctx.Err())
+	}
+}
+
+func (g *Group) run(name string, fn func(ctx context.Context)) {
+	defer func() {
+		if r := recover(); r != nil {
+			g.Logger.Error("Loop panicked", slog.String("loop", name), slog.Any("panic", r), slog.String("stack", string(debug.Stack())))
+		}
+	}()
+	fn(g.ctx)
+}
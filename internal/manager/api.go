@@ -2,16 +2,21 @@ package manager
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/httplog/v2"
 	"github.com/google/uuid"
 	"github.com/nduyhai/maestro/internal/httpx"
+	"github.com/nduyhai/maestro/internal/node"
 	"github.com/nduyhai/maestro/internal/task"
+	"github.com/nduyhai/maestro/internal/worker"
 )
 
 type API struct {
@@ -41,25 +46,48 @@ func (a *API) StartTaskHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := a.validate(te.Task); err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	a.Manager.AddTask(te)
-	a.Manager.SendWork()
 	a.Logger.Info(fmt.Sprintf("Task added: %v", te.Task))
 	w.WriteHeader(http.StatusCreated)
 	_ = json.NewEncoder(w).Encode(te.Task)
 }
 
+// validate rejects tasks the manager would never be able to place.
+func (a *API) validate(t task.Task) error {
+	if err := t.RestartPolicy.Validate(); err != nil {
+		return err
+	}
+	if t.HealthCheck != nil {
+		if err := t.HealthCheck.Validate(); err != nil {
+			return err
+		}
+	}
+	if _, err := a.Manager.Profiles.For(t); err != nil {
+		return err
+	}
+	_, err := t.HostPortRequests()
+	return err
+}
+
 func (a *API) StopTaskHandler(w http.ResponseWriter, r *http.Request) {
 	taskID := chi.URLParam(r, "taskID")
 	if taskID == "" {
 		a.Logger.Info("No taskID passed in request.")
-		w.WriteHeader(http.StatusNotFound)
+		httpx.WriteError(w, http.StatusBadRequest, "No taskID passed in request")
+		return
 	}
 
 	tID, _ := uuid.Parse(taskID)
-	taskToStop, ok := a.Manager.TaskDB[tID]
+	taskToStop, ok := a.Manager.GetTask(tID)
 	if !ok {
 		a.Logger.Info("No task with ID found", slog.String("taskID", taskID))
-		w.WriteHeader(http.StatusNotFound)
+		httpx.WriteError(w, http.StatusNotFound, fmt.Sprintf("No task with ID %v found", taskID))
+		return
 	}
 
 	te := task.Event{
@@ -72,7 +100,6 @@ func (a *API) StopTaskHandler(w http.ResponseWriter, r *http.Request) {
 	taskCopy.State = task.Completed
 	te.Task = taskCopy
 	a.Manager.AddTask(te)
-	a.Manager.SendWork()
 
 	a.Logger.Info("Added task event to stop task", slog.Any("tID", te.ID), slog.Any("ID", taskToStop.ID))
 	w.WriteHeader(http.StatusOK)
@@ -83,3 +110,231 @@ func (a *API) GetTasksHandler(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	_ = json.NewEncoder(w).Encode(a.Manager.GetTasks())
 }
+
+func (a *API) GetTaskHandler(w http.ResponseWriter, r *http.Request) {
+	tID, err := uuid.Parse(chi.URLParam(r, "taskID"))
+	if err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, fmt.Sprintf("Invalid taskID: %v", err))
+		return
+	}
+
+	t, ok := a.Manager.GetTask(tID)
+	if !ok {
+		httpx.WriteError(w, http.StatusNotFound, fmt.Sprintf("No task with ID %v found", tID))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(t)
+}
+
+func (a *API) GetNodesHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(a.Manager.GetNodes())
+}
+
+func (a *API) GetEventsHandler(w http.ResponseWriter, r *http.Request) {
+	var taskID uuid.UUID
+	if v := r.URL.Query().Get("task"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			httpx.WriteError(w, http.StatusBadRequest, fmt.Sprintf("Invalid task: %v", err))
+			return
+		}
+		taskID = id
+	}
+	var since time.Time
+	if v := r.URL.Query().Get("since"); v != "" {
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			httpx.WriteError(w, http.StatusBadRequest, fmt.Sprintf("Invalid since: %v", err))
+			return
+		}
+		since = t
+	}
+	var after uint64
+	if v := r.URL.Query().Get("after"); v != "" {
+		seq, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			httpx.WriteError(w, http.StatusBadRequest, fmt.Sprintf("Invalid after: %v", err))
+			return
+		}
+		after = seq
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(a.Manager.GetEvents(taskID, since, after))
+}
+
+func (a *API) AddTaintHandler(w http.ResponseWriter, r *http.Request) {
+	d := json.NewDecoder(r.Body)
+	d.DisallowUnknownFields()
+
+	var t task.Taint
+	if err := d.Decode(&t); err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, fmt.Sprintf("Error unmarshalling body: %v", err))
+		return
+	}
+	if _, err := task.ParseTaint(t.String()); err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	name := chi.URLParam(r, "name")
+	if err := a.Manager.AddTaint(name, t); err != nil {
+		httpx.WriteError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	a.Logger.Info("Tainted node", slog.String("node", name), slog.String("taint", t.String()))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (a *API) RemoveTaintHandler(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	key := chi.URLParam(r, "key")
+	if err := a.Manager.RemoveTaint(name, key); err != nil {
+		httpx.WriteError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	a.Logger.Info("Removed taint from node", slog.String("node", name), slog.String("key", key))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (a *API) CordonHandler(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	if err := a.Manager.CordonNode(name); err != nil {
+		httpx.WriteError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	a.Logger.Info("Cordoned node", slog.String("node", name))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (a *API) UncordonHandler(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	if err := a.Manager.UncordonNode(name); err != nil {
+		httpx.WriteError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	a.Logger.Info("Uncordoned node", slog.String("node", name))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DrainHandler starts draining a node. The body is optional; tasks are moved
+// one at a time unless it sets MaxUnavailable.
+func (a *API) DrainHandler(w http.ResponseWriter, r *http.Request) {
+	d := json.NewDecoder(r.Body)
+	d.DisallowUnknownFields()
+
+	var drain node.Drain
+	if err := d.Decode(&drain); err != nil && !errors.Is(err, io.EOF) {
+		httpx.WriteError(w, http.StatusBadRequest, fmt.Sprintf("Error unmarshalling body: %v", err))
+		return
+	}
+	if drain.MaxUnavailable < 0 {
+		httpx.WriteError(w, http.StatusBadRequest, fmt.Sprintf("max unavailable must be at least 1, got %d", drain.MaxUnavailable))
+		return
+	}
+	if drain.MaxUnavailable == 0 {
+		drain.MaxUnavailable = 1
+	}
+
+	name := chi.URLParam(r, "name")
+	if err := a.Manager.DrainNode(name, drain.MaxUnavailable); err != nil {
+		httpx.WriteError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	a.Logger.Info("Draining node", slog.String("node", name), slog.Int("maxUnavailable", drain.MaxUnavailable))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (a *API) ExplainHandler(w http.ResponseWriter, r *http.Request) {
+	d := json.NewDecoder(r.Body)
+	d.DisallowUnknownFields()
+
+	var t task.Task
+	if err := d.Decode(&t); err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, fmt.Sprintf("Error unmarshalling body: %v", err))
+		return
+	}
+
+	e, err := a.Manager.Explain(t)
+	if err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(e)
+}
+
+func (a *API) StartGroupHandler(w http.ResponseWriter, r *http.Request) {
+	d := json.NewDecoder(r.Body)
+	d.DisallowUnknownFields()
+
+	var g task.Group
+	if err := d.Decode(&g); err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, fmt.Sprintf("Error unmarshalling body: %v", err))
+		return
+	}
+	for _, t := range g.Tasks {
+		if err := a.validate(t); err != nil {
+			httpx.WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	g, err := a.Manager.AddGroup(g)
+	if err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	a.Logger.Info("Task group added", slog.Any("ID", g.ID), slog.String("name", g.Name), slog.Int("tasks", len(g.Tasks)))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(g)
+}
+
+func (a *API) GetGroupsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(a.Manager.GetGroups())
+}
+
+func (a *API) RegisterNodeHandler(w http.ResponseWriter, r *http.Request) {
+	d := json.NewDecoder(r.Body)
+	d.DisallowUnknownFields()
+
+	var reg worker.Registration
+	if err := d.Decode(&reg); err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, fmt.Sprintf("Error unmarshalling body: %v", err))
+		return
+	}
+	name, err := a.Manager.Register(reg)
+	if err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	reg.Name = name
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(reg)
+}
+
+func (a *API) HeartbeatHandler(w http.ResponseWriter, r *http.Request) {
+	d := json.NewDecoder(r.Body)
+	d.DisallowUnknownFields()
+
+	var hb worker.Heartbeat
+	if err := d.Decode(&hb); err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, fmt.Sprintf("Error unmarshalling body: %v", err))
+		return
+	}
+	if err := a.Manager.Heartbeat(r.Context(), chi.URLParam(r, "name"), hb); err != nil {
+		httpx.WriteError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
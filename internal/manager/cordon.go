@@ -0,0 +1,177 @@
+package manager
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/nduyhai/maestro/internal/node"
+	"github.com/nduyhai/maestro/internal/task"
+)
+
+// Cordon takes a node out of rotation: the schedulers place no new task on a
+// cordoned node. A draining node also has its tasks moved to other nodes, no
+// more than MaxUnavailable of them at a time. Moved are the tasks moved off
+// the node that are not ready elsewhere yet, kept with the cordon so that a
+// restarted manager does not exceed the budget.
+type Cordon struct {
+	Node           string
+	Drain          bool
+	MaxUnavailable int
+	Moved          []uuid.UUID
+}
+
+// CordonNode stops the schedulers from placing tasks on the named node.
+func (m *Manager) CordonNode(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.hasNode(name) {
+		return fmt.Errorf("%w: %s", ErrNodeNotFound, name)
+	}
+	c, ok := m.cordons[name]
+	if !ok {
+		c = Cordon{Node: name}
+	}
+	m.setCordon(c)
+	return nil
+}
+
+// DrainNode cordons the named node and moves its tasks to other nodes. A
+// task moved off the node counts against maxUnavailable until it runs again.
+func (m *Manager) DrainNode(name string, maxUnavailable int) error {
+	if maxUnavailable < 1 {
+		return fmt.Errorf("max unavailable must be at least 1, got %d", maxUnavailable)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.hasNode(name) {
+		return fmt.Errorf("%w: %s", ErrNodeNotFound, name)
+	}
+	m.setCordon(Cordon{Node: name, Drain: true, MaxUnavailable: maxUnavailable, Moved: m.cordons[name].Moved})
+	m.wakeDrain()
+	return nil
+}
+
+// UncordonNode puts the named node back in rotation, stopping any drain.
+func (m *Manager) UncordonNode(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.hasNode(name) {
+		return fmt.Errorf("%w: %s", ErrNodeNotFound, name)
+	}
+	delete(m.cordons, name)
+	if err := m.Store.Cordons.Delete(name); err != nil {
+		m.Logger.Error("Error removing cordon", slog.String("node", name), slog.Any("err", err))
+	}
+	return nil
+}
+
+// setCordon records and persists the cordon of a node; callers must hold m.mu.
+func (m *Manager) setCordon(c Cordon) {
+	m.cordons[c.Node] = c
+	if err := m.Store.Cordons.Put(c.Node, c); err != nil {
+		m.Logger.Error("Error persisting cordon", slog.String("node", c.Node), slog.Any("err", err))
+	}
+}
+
+// DrainNodes moves the tasks off the draining nodes, as many as their
+// disruption budget allows. A node whose tasks have all been moved and run
+// elsewhere stays cordoned.
+func (m *Manager) DrainNodes(ctx context.Context) {
+	for _, n := range m.GetNodes() {
+		if !n.Draining {
+			continue
+		}
+		for _, t := range m.drainBatch(n) {
+			m.Logger.Info("Draining task", slog.Any("ID", t.ID), slog.String("node", n.Name))
+			m.reschedule(ctx, n.Name, t, fmt.Sprintf("drained from node %s", n.Name))
+		}
+	}
+}
+
+// drainBatch returns the tasks of the draining node n to move next, lowest
+// priority first, and records them as moved.
+func (m *Manager) drainBatch(n *node.Node) []task.Task {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c, ok := m.cordons[n.Name]
+	if !ok || !c.Drain {
+		return nil
+	}
+	// A moved task is unavailable until it is ready again, or has stopped
+	// for good.
+	unavailable := 0
+	for id, from := range m.drained {
+		t, ok := m.TaskDB[id]
+		if !ok || t.Ready() || t.State == task.Completed || t.State == task.Failed {
+			delete(m.drained, id)
+			continue
+		}
+		if from == n.Name {
+			unavailable++
+		}
+	}
+	// n may be older than a task moved on the previous pass.
+	left := slices.DeleteFunc(slices.Clone(n.Tasks), func(t task.Task) bool {
+		_, moved := m.drained[t.ID]
+		return moved
+	})
+	if len(left) == 0 {
+		if unavailable == 0 {
+			m.Logger.Info("Node drained", slog.String("node", n.Name))
+			m.setCordon(Cordon{Node: n.Name})
+		} else {
+			m.saveMoved(c)
+		}
+		return nil
+	}
+
+	tasks := slices.SortedFunc(slices.Values(left), func(a, b task.Task) int {
+		return cmp.Compare(a.Priority, b.Priority)
+	})
+	batch := tasks[:min(max(c.MaxUnavailable-unavailable, 0), len(tasks))]
+	for _, t := range batch {
+		m.drained[t.ID] = n.Name
+	}
+	m.saveMoved(c)
+	return batch
+}
+
+// saveMoved persists the tasks moved off the node of c that are still
+// unavailable, when they changed; callers must hold m.mu.
+func (m *Manager) saveMoved(c Cordon) {
+	var moved []uuid.UUID
+	for id, from := range m.drained {
+		if from == c.Node {
+			moved = append(moved, id)
+		}
+	}
+	slices.SortFunc(moved, func(a, b uuid.UUID) int { return bytes.Compare(a[:], b[:]) })
+	if slices.Equal(moved, c.Moved) {
+		return
+	}
+	c.Moved = moved
+	m.setCordon(c)
+}
+
+// wakeDrain runs the drain loop without waiting for its next tick.
+func (m *Manager) wakeDrain() {
+	select {
+	case m.drainWake <- struct{}{}:
+	default:
+	}
+}
+
+// DrainWake fires when a node starts draining, and when a task moved off a
+// draining node changes state.
+func (m *Manager) DrainWake() <-chan struct{} {
+	return m.drainWake
+}
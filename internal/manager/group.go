@@ -0,0 +1,200 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nduyhai/maestro/internal/scheduler"
+	"github.com/nduyhai/maestro/internal/task"
+	"github.com/samber/lo"
+)
+
+// AddGroup records the members of g as pending and queues the group to be
+// placed as a whole. The group is dispatched with the priority of its first
+// member.
+func (m *Manager) AddGroup(g task.Group) (task.Group, error) {
+	if len(g.Tasks) == 0 {
+		return task.Group{}, errors.New("task group has no tasks")
+	}
+	if g.ID == uuid.Nil {
+		g.ID = uuid.New()
+	}
+	if g.Name == "" {
+		g.Name = "group-" + g.ID.String()[:8]
+	}
+	now := time.Now().UTC()
+	if g.Timeout > 0 {
+		g.Deadline = now.Add(g.Timeout)
+	}
+	g.Placed = false
+	for i := range g.Tasks {
+		t := &g.Tasks[i]
+		if t.ID == uuid.Nil {
+			t.ID = uuid.New()
+		}
+		if t.Name == "" {
+			t.Name = fmt.Sprintf("%s-%d", g.Name, i)
+		}
+		t.Group = g.ID
+		t.State = task.Pending
+	}
+
+	m.mu.Lock()
+	for _, t := range g.Tasks {
+		if _, ok := m.TaskDB[t.ID]; ok {
+			m.mu.Unlock()
+			return task.Group{}, fmt.Errorf("task %v already exists", t.ID)
+		}
+	}
+	for _, t := range g.Tasks {
+		m.TaskDB[t.ID] = &t
+		m.saveTask(&t)
+	}
+	m.saveGroup(&g)
+	m.mu.Unlock()
+
+	m.AddTask(task.Event{ID: uuid.New(), State: task.Scheduled, Timestamp: now, Task: g.Tasks[0]})
+	return g, nil
+}
+
+// GetGroups returns a snapshot of the task groups.
+func (m *Manager) GetGroups() []*task.Group {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return lo.MapToSlice(m.groups, func(_ uuid.UUID, g *task.Group) *task.Group {
+		c := *g
+		return &c
+	})
+}
+
+func (m *Manager) groupPlaced(id uuid.UUID) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	g, ok := m.groups[id]
+	return ok && g.Placed
+}
+
+// dispatchGroup places every member of the group of te at once, or none of
+// them. It reports whether the group has to be retried later.
+func (m *Manager) dispatchGroup(ctx context.Context, te task.Event) bool {
+	m.mu.RLock()
+	g, ok := m.groups[te.Task.Group]
+	var members []task.Task
+	if ok {
+		members = lo.FilterMap(g.Tasks, func(t task.Task, _ int) (task.Task, bool) {
+			member, found := m.TaskDB[t.ID]
+			if !found {
+				return task.Task{}, false
+			}
+			return *member, true
+		})
+	}
+	m.mu.RUnlock()
+	if !ok {
+		m.Logger.Error("Task group not found", slog.Any("group", te.Task.Group))
+		return false
+	}
+
+	if stopped, found := lo.Find(members, func(t task.Task) bool { return t.State == task.Completed }); found {
+		m.failGroup(g.ID, task.Completed, fmt.Sprintf("group %s cancelled: task %s was stopped", g.Name, stopped.Name))
+		return false
+	}
+
+	nodes, err := scheduler.PlaceGroup(m.Profiles, members, m.GetNodes())
+	if err != nil {
+		if !g.Deadline.IsZero() && time.Now().After(g.Deadline) {
+			m.failGroup(g.ID, task.Failed, fmt.Sprintf("group %s could not be placed within %s: %v", g.Name, g.Timeout, err))
+			return false
+		}
+		m.Logger.Info("Task group does not fit yet", slog.String("group", g.Name), slog.Any("err", err))
+		return true
+	}
+
+	m.mu.Lock()
+	placed := *m.groups[g.ID]
+	placed.Placed = true
+	m.saveGroup(&placed)
+	m.mu.Unlock()
+
+	for i, t := range members {
+		t.State = task.Scheduled
+		ev := task.Event{
+			ID:        uuid.New(),
+			State:     task.Scheduled,
+			Timestamp: time.Now().UTC(),
+			Task:      t,
+			Message:   fmt.Sprintf("placed with group %s on node %s", g.Name, nodes[i].Name),
+		}
+		m.mu.Lock()
+		m.recordEvent(ev)
+		m.mu.Unlock()
+		// Once the group is placed, a member whose worker cannot be
+		// reached is retried on its own. One the worker rejects fails the
+		// whole group, which cannot run without it.
+		retry, err := m.submit(ctx, nodes[i].Name, ev)
+		if err != nil {
+			m.abortGroup(ctx, g.ID, fmt.Sprintf("group %s failed: task %s %v", g.Name, t.Name, err))
+			return false
+		}
+		if retry {
+			m.requeue(ev)
+		}
+	}
+	return false
+}
+
+// abortGroup fails the members of a placed group that have not stopped yet,
+// stopping those already sent to a worker.
+func (m *Manager) abortGroup(ctx context.Context, id uuid.UUID, reason string) {
+	m.Logger.Info("Task group failed", slog.Any("group", id), slog.String("reason", reason))
+	m.mu.RLock()
+	members := m.groups[id].Tasks
+	m.mu.RUnlock()
+
+	for _, member := range members {
+		m.mu.RLock()
+		worker, assigned := m.TaskWorkerMap[member.ID]
+		t, ok := m.TaskDB[member.ID]
+		m.mu.RUnlock()
+		if !ok || t.State == task.Completed || t.State == task.Failed {
+			continue
+		}
+		if assigned {
+			m.stopTask(ctx, worker, member.ID.String())
+		}
+		m.failTask(member.ID, reason)
+	}
+}
+
+// failGroup moves the members of a group that are still pending to state
+// and records why.
+func (m *Manager) failGroup(id uuid.UUID, state task.State, reason string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.Logger.Info("Task group not placed", slog.Any("group", id), slog.String("reason", reason))
+	for _, member := range m.groups[id].Tasks {
+		t, ok := m.TaskDB[member.ID]
+		if !ok || t.State != task.Pending {
+			continue
+		}
+		c := *t
+		c.State = state
+		c.FinishTime = time.Now().UTC()
+		m.TaskDB[c.ID] = &c
+		m.saveTask(&c)
+		m.recordEvent(task.Event{ID: uuid.New(), State: state, Timestamp: c.FinishTime, Task: c, Message: reason})
+	}
+}
+
+// saveGroup records and persists g; callers must hold m.mu.
+func (m *Manager) saveGroup(g *task.Group) {
+	m.groups[g.ID] = g
+	if err := m.Store.Groups.Put(g.ID.String(), *g); err != nil {
+		m.Logger.Error("Error persisting task group", slog.Any("ID", g.ID), slog.Any("err", err))
+	}
+}
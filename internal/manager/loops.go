@@ -0,0 +1,146 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/nduyhai/maestro/internal/loop"
+	"github.com/nduyhai/maestro/internal/node"
+	"github.com/nduyhai/maestro/internal/stats"
+	"github.com/nduyhai/maestro/internal/task"
+	"github.com/nduyhai/maestro/internal/worker"
+)
+
+type LoopConfig struct {
+	DispatchInterval  time.Duration
+	ReconcileInterval time.Duration
+	HealthInterval    time.Duration
+}
+
+// RunLoops starts the control loops of the manager in g: dispatching pending
+// tasks, syncing task state from workers, checking worker health, evicting
+// tasks from nodes tainted NoExecute and draining nodes.
+func (m *Manager) RunLoops(g *loop.Group, cfg LoopConfig) error {
+	return errors.Join(
+		g.Every("dispatch", cfg.DispatchInterval, m.Wake(), m.DispatchPending),
+		g.Every("reconcile", cfg.ReconcileInterval, nil, m.UpdateTasks),
+		g.Every("health", cfg.HealthInterval, nil, m.CheckWorkers),
+		g.Every("evict", cfg.ReconcileInterval, m.EvictWake(), m.EvictTasks),
+		g.Every("drain", cfg.ReconcileInterval, m.DrainWake(), m.DrainNodes),
+	)
+}
+
+// DispatchPending tries to send every event in the pending queue, most
+// urgent first. Events that cannot be placed are requeued once the queue has
+// been drained, so that they do not hold up the events behind them, and are
+// retried on the next run. Events whose delay has not passed, such as backed
+// off restarts, are put back as they are.
+func (m *Manager) DispatchPending(ctx context.Context) {
+	var retry, delayed []task.Event
+	for ctx.Err() == nil {
+		now := time.Now()
+		te, ok := m.nextPending(now)
+		if !ok {
+			break
+		}
+		if now.Before(te.NotBefore) {
+			delayed = append(delayed, te)
+			continue
+		}
+		if m.dispatch(ctx, te) {
+			retry = append(retry, te)
+			continue
+		}
+		m.done(te)
+	}
+	for _, te := range retry {
+		m.requeue(te)
+	}
+	for _, te := range delayed {
+		m.hold(te)
+	}
+}
+
+// CheckWorkers probes the static workers and marks the registered ones that
+// stopped sending heartbeats as NotReady. The tasks of nodes that stay
+// unreachable for the lost timeout are rescheduled.
+func (m *Manager) CheckWorkers(ctx context.Context) {
+	for _, n := range m.GetNodes() {
+		if m.isRegistered(n.Name) {
+			m.checkHeartbeat(n.Name)
+			continue
+		}
+		m.setNodeStatus(n.Name, m.probe(ctx, n.Name))
+	}
+	for _, name := range m.markLost() {
+		m.evacuate(name)
+	}
+}
+
+// nodeReport is what a health check learned about a worker. Stats and Info
+// are nil when the worker could not provide them.
+type nodeReport struct {
+	Err   error
+	Stats *stats.Stats
+	Info  *worker.Info
+}
+
+func (m *Manager) probe(ctx context.Context, name string) nodeReport {
+	c := m.workerClient(name)
+	if err := c.Health(ctx); err != nil {
+		return nodeReport{Err: err}
+	}
+	var r nodeReport
+	s, err := c.GetStats(ctx)
+	if err != nil {
+		m.Logger.Error("Error collecting worker stats", slog.String("worker", name), slog.Any("err", err))
+	} else {
+		r.Stats = s
+	}
+	info, err := c.GetInfo(ctx)
+	if err != nil {
+		m.Logger.Error("Error collecting worker info", slog.String("worker", name), slog.Any("err", err))
+	} else {
+		r.Info = info
+	}
+	return r
+}
+
+// setNodeStatus records the outcome of a health check of the named worker.
+func (m *Manager) setNodeStatus(name string, r nodeReport) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, n := range m.WorkerNodes {
+		if n.Name != name {
+			continue
+		}
+		if r.Err != nil {
+			if n.Status != node.NotReady && n.Status != node.Lost {
+				m.Logger.Error("Worker is not ready", slog.String("worker", n.Name), slog.Any("err", r.Err))
+				n.Status = node.NotReady
+			}
+			return
+		}
+		if n.Status != node.Ready {
+			m.Logger.Info("Worker is ready", slog.String("worker", n.Name))
+		}
+		n.Status = node.Ready
+		n.LastSeen = time.Now().UTC()
+		if r.Stats != nil {
+			n.Cores = r.Stats.Cores
+			n.Memory = r.Stats.MemoryTotal()
+			n.Disk = r.Stats.DiskTotal()
+			n.Stats = r.Stats
+		}
+		if r.Info != nil {
+			n.Zone = r.Info.Zone
+			n.Rack = r.Info.Rack
+			n.Labels = r.Info.Labels
+			n.Taints = r.Info.Taints
+		}
+		return
+	}
+}
@@ -0,0 +1,81 @@
+package manager
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nduyhai/maestro/internal/node"
+	"github.com/nduyhai/maestro/internal/task"
+	"github.com/samber/lo"
+)
+
+// markLost marks the nodes that have not been reachable for longer than the
+// lost timeout as lost and returns their names.
+func (m *Manager) markLost() []string {
+	if m.Config.LostTimeout <= 0 {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var lost []string
+	for _, n := range m.WorkerNodes {
+		if n.Status != node.NotReady || time.Since(m.lastContact(n)) <= m.Config.LostTimeout {
+			continue
+		}
+		m.Logger.Error("Worker is lost", slog.String("worker", n.Name), slog.Time("lastSeen", n.LastSeen))
+		n.Status = node.Lost
+		lost = append(lost, n.Name)
+	}
+	return lost
+}
+
+// evacuate takes the scheduled and running tasks off a lost node. Losing the
+// node is no failure of theirs, so they are queued to be placed on another
+// node whatever their restart budget, except for those whose restart policy
+// is set to never, which fail. Copies left running on the node are stopped
+// once it reports them again.
+func (m *Manager) evacuate(name string) {
+	m.mu.RLock()
+	tasks := lo.FilterMap(m.WorkerTaskMap[name], func(id uuid.UUID, _ int) (task.Task, bool) {
+		t, ok := m.TaskDB[id]
+		if !ok || (t.State != task.Scheduled && t.State != task.Running) {
+			return task.Task{}, false
+		}
+		return *t, true
+	})
+	m.mu.RUnlock()
+
+	for _, t := range tasks {
+		reason := fmt.Sprintf("lost with node %s", name)
+		if t.RestartPolicy == task.RestartNever || t.RestartPolicy == "no" {
+			m.Logger.Info("Failing task of lost worker", slog.Any("ID", t.ID), slog.String("worker", name))
+			m.failTask(t.ID, reason)
+			continue
+		}
+		m.Logger.Info("Rescheduling task of lost worker", slog.Any("ID", t.ID), slog.String("worker", name))
+		m.requeueTask(t, reason+", rescheduling")
+	}
+}
+
+// failTask takes a task off its worker and marks it failed for reason.
+func (m *Manager) failTask(id uuid.UUID, reason string) (task.Task, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.dropAssignment(id)
+	t, ok := m.TaskDB[id]
+	if !ok {
+		return task.Task{}, false
+	}
+	failed := *t
+	failed.State = task.Failed
+	failed.FinishTime = time.Now().UTC()
+	failed.ExitReason = reason
+	m.TaskDB[id] = &failed
+	m.saveTask(&failed)
+	m.recordEvent(task.Event{ID: uuid.New(), State: task.Failed, Timestamp: failed.FinishTime, Task: failed, Message: reason})
+	return failed, true
+}
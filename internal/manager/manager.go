@@ -1,18 +1,24 @@
 package manager
 
 import (
-	"encoding/json"
+	"cmp"
+	"context"
 	"errors"
 	"fmt"
 	"log/slog"
 	"maps"
-	"net/http"
 	"slices"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/nduyhai/maestro/client"
 	"github.com/nduyhai/maestro/internal/node"
 	"github.com/nduyhai/maestro/internal/scheduler"
+	"github.com/nduyhai/maestro/internal/worker"
 
-	"github.com/emirpasic/gods/queues/arrayqueue"
+	"github.com/emirpasic/gods/queues/priorityqueue"
+	"github.com/emirpasic/gods/utils"
 	"github.com/go-chi/httplog/v2"
 	"github.com/nduyhai/maestro/internal/httpx"
 	"github.com/samber/lo"
@@ -24,11 +30,29 @@ import (
 	"github.com/google/uuid"
 )
 
+// Config holds the scheduling policies of a manager.
+type Config struct {
+	// Preemption lets a task that fits nowhere stop tasks of a lower priority.
+	Preemption bool
+	// PriorityAging is how long a pending event waits to gain one point of
+	// priority, so that low priority work is not starved. Zero disables aging.
+	PriorityAging time.Duration
+	// NodeTimeout is how long a registered worker may go without a heartbeat
+	// before its node is marked NotReady.
+	NodeTimeout time.Duration
+	// LostTimeout is how long a node may stay unreachable before its tasks
+	// are taken off it and rescheduled. Zero disables it.
+	LostTimeout time.Duration
+	// RestartBackoff is the delay before the first restart of a task, doubled
+	// for each further restart up to MaxRestartBackoff.
+	RestartBackoff    time.Duration
+	MaxRestartBackoff time.Duration
+}
+
 type Manager struct {
 	Pending       queues.Queue
 	TaskDB        map[uuid.UUID]*task.Task
 	EventDB       map[uuid.UUID]*task.Event
-	Workers       []string
 	WorkerTaskMap map[string][]uuid.UUID
 	TaskWorkerMap map[uuid.UUID]string
 	LastWorker    int
@@ -36,10 +60,37 @@ type Manager struct {
 	Logger        *httplog.Logger
 
 	WorkerNodes []*node.Node
-	Scheduler   scheduler.Scheduler
+	Profiles    *scheduler.Profiles
+	Store       *Store
+	Config      Config
+
+	// mu guards the task, event, assignment and group maps, the pending
+	// queue, the worker nodes, the taints and the client cache.
+	mu      sync.RWMutex
+	clients map[string]*client.Client
+	wake    chan struct{}
+
+	// taints are the taints applied through the API, by node name.
+	taints    map[string][]task.Taint
+	evictWake chan struct{}
+
+	groups map[uuid.UUID]*task.Group
+
+	// registered are the nodes that registered themselves and send
+	// heartbeats; the others are the static workers, which are polled.
+	registered map[string]bool
+	started    time.Time
+	// eventSeq is the sequence number of the last recorded event.
+	eventSeq uint64
+
+	// cordons are the cordoned nodes, by name. drained maps the tasks being
+	// moved off a draining node to that node.
+	cordons   map[string]Cordon
+	drained   map[uuid.UUID]string
+	drainWake chan struct{}
 }
 
-func NewManager(logger *httplog.Logger, client *resty.Client, workers []string) *Manager {
+func NewManager(logger *httplog.Logger, restClient *resty.Client, workers []string, store *Store, profiles *scheduler.Profiles, cfg Config) (*Manager, error) {
 
 	workerTaskMap := make(map[string][]uuid.UUID)
 	var nodes []*node.Node
@@ -49,162 +100,587 @@ func NewManager(logger *httplog.Logger, client *resty.Client, workers []string)
 		n := node.NewNode(workers[w], nAPI)
 		nodes = append(nodes, n)
 	}
-	return &Manager{
-		Pending:       arrayqueue.New(),
+	m := &Manager{
+		Pending:       priorityqueue.NewWith(pendingOrder(cfg.PriorityAging)),
 		TaskDB:        make(map[uuid.UUID]*task.Task),
 		EventDB:       make(map[uuid.UUID]*task.Event),
-		Workers:       workers,
 		WorkerTaskMap: workerTaskMap,
 		TaskWorkerMap: make(map[uuid.UUID]string),
 		LastWorker:    0,
-		Client:        client,
+		Client:        restClient,
 		Logger:        logger,
-		Scheduler: &scheduler.RoundRobin{
-			Name:       "roundrobin",
-			LastWorker: 0,
-		},
+		Profiles:      profiles,
+		WorkerNodes:   nodes,
+		Store:         store,
+		Config:        cfg,
+		clients:       make(map[string]*client.Client),
+		wake:          make(chan struct{}, 1),
+		taints:        make(map[string][]task.Taint),
+		evictWake:     make(chan struct{}, 1),
+		groups:        make(map[uuid.UUID]*task.Group),
+		registered:    make(map[string]bool),
+		cordons:       make(map[string]Cordon),
+		drained:       make(map[uuid.UUID]string),
+		drainWake:     make(chan struct{}, 1),
+		started:       time.Now().UTC(),
+	}
+	if err := m.restore(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// restore rebuilds the in-memory view of the manager from its store.
+func (m *Manager) restore() error {
+	tasks, err := m.Store.Tasks.List()
+	if err != nil {
+		return fmt.Errorf("load tasks: %w", err)
+	}
+	for _, t := range tasks {
+		m.TaskDB[t.ID] = &t
+	}
+
+	events, err := m.Store.Events.List()
+	if err != nil {
+		return fmt.Errorf("load events: %w", err)
+	}
+	// Events recorded before sequence numbers existed are numbered in time
+	// order, after the others.
+	slices.SortStableFunc(events, func(a, b task.Event) int {
+		return a.Timestamp.Compare(b.Timestamp)
+	})
+	for _, e := range events {
+		m.eventSeq = max(m.eventSeq, e.Seq)
+	}
+	for _, e := range events {
+		if e.Seq == 0 {
+			m.eventSeq++
+			e.Seq = m.eventSeq
+		}
+		m.EventDB[e.ID] = &e
+	}
+
+	assignments, err := m.Store.Assignments.List()
+	if err != nil {
+		return fmt.Errorf("load assignments: %w", err)
+	}
+	for _, a := range assignments {
+		m.TaskWorkerMap[a.TaskID] = a.Worker
+		m.WorkerTaskMap[a.Worker] = append(m.WorkerTaskMap[a.Worker], a.TaskID)
+	}
+
+	pending, err := m.Store.Pending.List()
+	if err != nil {
+		return fmt.Errorf("load pending events: %w", err)
+	}
+	slices.SortStableFunc(pending, func(a, b task.Event) int {
+		return a.Timestamp.Compare(b.Timestamp)
+	})
+	for _, e := range pending {
+		m.Pending.Enqueue(e)
+	}
+
+	taints, err := m.Store.Taints.List()
+	if err != nil {
+		return fmt.Errorf("load taints: %w", err)
+	}
+	for _, nt := range taints {
+		m.taints[nt.Node] = nt.Taints
+	}
+
+	groups, err := m.Store.Groups.List()
+	if err != nil {
+		return fmt.Errorf("load groups: %w", err)
+	}
+	for _, g := range groups {
+		m.groups[g.ID] = &g
+	}
+
+	cordons, err := m.Store.Cordons.List()
+	if err != nil {
+		return fmt.Errorf("load cordons: %w", err)
+	}
+	for _, c := range cordons {
+		m.cordons[c.Node] = c
+		for _, id := range c.Moved {
+			m.drained[id] = c.Node
+		}
+	}
+
+	nodes, err := m.Store.Nodes.List()
+	if err != nil {
+		return fmt.Errorf("load nodes: %w", err)
+	}
+	for _, n := range nodes {
+		m.restoreNode(n)
+	}
+
+	m.Logger.Info("Restored manager state",
+		slog.Int("tasks", len(tasks)),
+		slog.Int("events", len(events)),
+		slog.Int("assignments", len(assignments)),
+		slog.Int("pending", len(pending)),
+		slog.Int("nodes", len(nodes)))
+	return nil
+}
+
+// pendingOrder puts the event with the highest priority first, counting one
+// extra point for every aging period it has been waiting, then the oldest.
+// As every event ages at the same rate the order does not change over time.
+func pendingOrder(aging time.Duration) utils.Comparator {
+	effective := func(e task.Event) float64 {
+		p := float64(e.Task.Priority)
+		if aging > 0 {
+			p -= float64(e.Timestamp.UnixNano()) / float64(aging)
+		}
+		return p
+	}
+	return func(a, b any) int {
+		x, y := a.(task.Event), b.(task.Event)
+		return cmp.Or(
+			cmp.Compare(effective(y), effective(x)),
+			x.Timestamp.Compare(y.Timestamp),
+			strings.Compare(x.ID.String(), y.ID.String()),
+		)
 	}
 }
 
 func (m *Manager) SelectWorker(t task.Task) (*node.Node, error) {
 	m.Logger.Info("I will select an appropriate worker")
 
-	candidates := m.Scheduler.SelectCandidateNodes(t, m.WorkerNodes)
-	if candidates == nil {
-		msg := fmt.Sprintf("No available candidates match resource request for task %v", t.ID)
-		err := errors.New(msg)
+	s, err := m.Profiles.For(t)
+	if err != nil {
 		return nil, err
 	}
-	scores := m.Scheduler.Score(t, candidates)
-	selectedNode := m.Scheduler.Pick(scores, candidates)
+	nodes := m.GetNodes()
+	candidates := s.SelectCandidateNodes(t, nodes)
+	if len(candidates) == 0 {
+		return nil, scheduler.Unschedulable(s, t, nodes)
+	}
+	scores := s.Score(t, candidates)
+	selectedNode := s.Pick(scores, candidates)
 
 	return selectedNode, nil
 }
 
-func (m *Manager) UpdateTasks() {
+// Explain runs the scheduler against t without placing it. When no node fits
+// and preemption is enabled, it also reports the tasks that would be preempted.
+func (m *Manager) Explain(t task.Task) (scheduler.Explanation, error) {
+	s, err := m.Profiles.For(t)
+	if err != nil {
+		return scheduler.Explanation{}, err
+	}
+	nodes := m.GetNodes()
+	e := scheduler.Explain(s, t, nodes)
+	if e.Selected == "" && m.Config.Preemption {
+		e.Preemption = scheduler.ExplainPreemption(scheduler.Preempt(s, t, nodes))
+	}
+	return e, nil
+}
+
+// UpdateTasks pulls the state of their tasks from the workers that do not
+// send heartbeats.
+func (m *Manager) UpdateTasks(ctx context.Context) {
 	m.Logger.Info("I will update tasks")
-	for _, w := range m.Workers {
+	for _, n := range m.GetNodes() {
+		w := n.Name
+		if m.isRegistered(w) {
+			continue
+		}
 		m.Logger.Info("Checking worker %v for task updates", slog.Any("worker", w))
-		url := fmt.Sprintf("http://%s/tasks", w)
-		resp, err := m.Client.R().Get(url)
+		tasks, err := m.workerClient(w).ListTasks(ctx)
 		if err != nil {
 			m.Logger.Error("Error connecting to ", slog.Any("worker", w), slog.Any("err", err))
 			continue
 		}
+		m.syncTasks(ctx, w, lo.Map(tasks, func(t *task.Task, _ int) worker.TaskSummary { return worker.Summarize(t) }))
+	}
+}
 
-		if resp.StatusCode() != http.StatusOK {
-			m.Logger.Error("Error sending request", slog.Any("err", err))
+// syncTasks records the state of the tasks reported by the named worker and
+// applies the restart policy of those that stopped. Copies of tasks that have
+// been moved off the worker, e.g. while it was lost, are stopped so that they
+// do not run twice.
+func (m *Manager) syncTasks(ctx context.Context, w string, tasks []worker.TaskSummary) {
+	var stale []uuid.UUID
+	var stopped []task.Task
+	m.mu.Lock()
+	for _, t := range tasks {
+		persisted, ok := m.TaskDB[t.ID]
+		if !ok {
+			m.Logger.Error("Task with ID not found", slog.Any("ID", t.ID))
 			continue
 		}
-
-		d := json.NewDecoder(resp.Body)
-		var tasks []*task.Task
-		err = d.Decode(&tasks)
-		if err != nil {
-			m.Logger.Error("Error unmarshalling tasks", slog.Any("err", err))
+		if m.TaskWorkerMap[t.ID] != w {
+			if t.State == task.Scheduled || t.State == task.Running {
+				stale = append(stale, t.ID)
+			}
 			continue
 		}
-		for _, t := range tasks {
-			m.Logger.Error("Attempting to update task", slog.Any("ID", t.ID))
 
-			_, ok := m.TaskDB[t.ID]
-			if !ok {
-				m.Logger.Error("Task with ID not found", slog.Any("ID", t.ID))
-				return
-			}
+		updated := *persisted
+		updated.State = t.State
+		updated.StartTime = t.StartTime
+		updated.FinishTime = t.FinishTime
+		updated.ContainerID = t.ContainerID
+		updated.HostPorts = t.HostPorts
+		updated.ExitReason = t.ExitReason
+		updated.Health = t.Health
+		m.TaskDB[t.ID] = &updated
+		m.saveTask(&updated)
+		if updated.State != persisted.State && (updated.State == task.Failed || updated.State == task.Completed) {
+			stopped = append(stopped, updated)
+		}
+		if _, drained := m.drained[t.ID]; drained && (updated.State != persisted.State || updated.Health != persisted.Health) {
+			m.wakeDrain()
+		}
+	}
+	m.mu.Unlock()
 
-			if m.TaskDB[t.ID].State != t.State {
-				m.TaskDB[t.ID].State = t.State
-			}
+	for _, t := range stopped {
+		// The restart may land on another worker, so the exited container
+		// is removed from this one first.
+		if t.ShouldRestart() {
+			m.stopTask(ctx, w, t.ID.String())
+		}
+		m.restart(t)
+	}
 
-			m.TaskDB[t.ID].StartTime = t.StartTime
-			m.TaskDB[t.ID].FinishTime = t.FinishTime
-			m.TaskDB[t.ID].ContainerID = t.ContainerID
+	for _, id := range stale {
+		m.Logger.Info("Stopping stale copy of task", slog.Any("ID", id), slog.String("worker", w))
+		if err := m.workerClient(w).StopTask(ctx, id); err != nil {
+			m.Logger.Error("Error stopping task", slog.Any("ID", id), slog.String("worker", w), slog.Any("err", err))
 		}
 	}
 }
 
-func (m *Manager) SendWork() {
+// SendWork dispatches the next pending event.
+func (m *Manager) SendWork(ctx context.Context) {
 	m.Logger.Info("I will send work to workers")
-	if m.Pending.Size() > 0 {
-		e, _ := m.Pending.Dequeue()
-		te := e.(task.Event)
-		t := te.Task
-		m.Logger.Info("Pulled %v off pending queue", slog.Any("task", t))
+	now := time.Now()
+	te, ok := m.nextPending(now)
+	if !ok {
+		m.Logger.Info("No work in the queue")
+		return
+	}
+	if now.Before(te.NotBefore) {
+		m.hold(te)
+		return
+	}
+	if m.dispatch(ctx, te) {
+		m.requeue(te)
+		return
+	}
+	m.done(te)
+}
 
-		m.EventDB[te.ID] = &te
+// dispatch carries out a pending event and reports whether it has to be
+// retried later.
+func (m *Manager) dispatch(ctx context.Context, te task.Event) bool {
+	t := te.Task
+	m.Logger.Info("Pulled %v off pending queue", slog.Any("task", t))
 
-		t = te.Task
-		w, err := m.SelectWorker(t)
-		if err != nil {
-			m.Logger.Error("Error selecting worker", slog.Any("err", err))
-			return
-		}
+	m.mu.Lock()
+	taskWorker, assigned := m.TaskWorkerMap[te.Task.ID]
+	var persisted task.Task
+	if p, ok := m.TaskDB[te.Task.ID]; ok {
+		persisted = *p
+	}
+	m.mu.Unlock()
 
-		m.WorkerTaskMap[w.Name] = append(m.WorkerTaskMap[w.Name], te.Task.ID)
-		m.TaskWorkerMap[t.ID] = w.Name
+	switch {
+	case !assigned && te.State == task.Completed:
+		m.cancel(t.ID)
+		return false
+	case t.Group != uuid.Nil && te.State == task.Scheduled && !m.groupPlaced(t.Group):
+		return m.dispatchGroup(ctx, te)
+	case !assigned && (persisted.State == task.Completed || persisted.State == task.Failed):
+		m.Logger.Info("Dropping event of a task stopped while pending", slog.Any("ID", t.ID))
+		return false
+	}
 
-		t.State = task.Scheduled
-		m.TaskDB[t.ID] = &t
-		data, err := json.Marshal(te)
-		if err != nil {
-			m.Logger.Info("Unable to marshal task object", slog.Any("task", t))
-			return
-		}
-		url := fmt.Sprintf("http://%s/tasks", w.Name)
-		resp, err := m.Client.R().SetBody(data).SetContentType("application/json").Post(url)
-		if err != nil {
-			m.Logger.Error("Error connecting to", slog.Any("worker", w), slog.Any("err", err))
-			m.Pending.Enqueue(te)
-			return
+	if assigned {
+		if te.State == task.Completed && task.ValidStateTransition(persisted.State, te.State) {
+			m.stopTask(ctx, taskWorker, te.Task.ID.String())
+			return false
 		}
+		m.Logger.Info("Invalid request: existing task cannot transition",
+			slog.Any("ID", persisted.ID), slog.Any("from", persisted.State), slog.Any("to", te.State))
+		return false
+	}
 
-		d := json.NewDecoder(resp.Body)
-		if resp.StatusCode() != http.StatusCreated {
-			e := httpx.ErrResponse{}
-			err := d.Decode(&e)
-			if err != nil {
-				m.Logger.Error("Error decoding response", slog.Any("err", err))
-				return
-			}
-			m.Logger.Info("Response error", slog.Any("statusCode", e.HTTPStatusCode), slog.Any("error", e))
-			return
-		}
-		t = task.Task{}
-		err = d.Decode(&t)
-		if err != nil {
-			m.Logger.Error("Error decoding response", slog.Any("err", err))
-			return
+	w, err := m.SelectWorker(t)
+	if err != nil && m.Config.Preemption && te.State == task.Scheduled && m.preempt(ctx, t) {
+		// Claim the room freed by the victims before they are placed again.
+		w, err = m.SelectWorker(t)
+	}
+	if err != nil {
+		m.Logger.Error("Error selecting worker", slog.Any("err", err))
+		return true
+	}
+
+	retry, err := m.submit(ctx, w.Name, te)
+	if failed, ok := m.GetTask(t.ID); err != nil && ok {
+		m.restart(*failed)
+	}
+	return retry
+}
+
+// submit assigns the task of te to the named worker and sends it there. It
+// reports whether the event has to be retried because the worker could not
+// be reached. A task the worker rejects is failed, and the error says why.
+func (m *Manager) submit(ctx context.Context, worker string, te task.Event) (bool, error) {
+	t := te.Task
+	t.State = task.Scheduled
+	t.Node = worker
+	m.assign(t)
+
+	created, err := m.workerClient(worker).SubmitTask(ctx, te)
+	if err != nil {
+		var errResp *httpx.ErrResponse
+		if errors.As(err, &errResp) {
+			m.Logger.Info("Response error", slog.Any("statusCode", errResp.HTTPStatusCode), slog.Any("error", errResp))
+			reason := fmt.Sprintf("rejected by worker %s: %s", worker, errResp.Message)
+			m.failTask(t.ID, reason)
+			return false, errors.New(reason)
 		}
-		m.Logger.Info("task ", slog.Any("task", t))
+		m.Logger.Error("Error connecting to", slog.String("worker", worker), slog.Any("err", err))
+		m.unassign(t.ID)
+		return true, nil
+	}
+	m.Logger.Info("task ", slog.Any("task", created))
+	return false, nil
+}
+
+// cancel marks a task that was stopped before being placed as completed.
+func (m *Manager) cancel(id uuid.UUID) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.TaskDB[id]
+	if !ok {
+		return
+	}
+	cancelled := *t
+	cancelled.State = task.Completed
+	cancelled.FinishTime = time.Now().UTC()
+	m.TaskDB[id] = &cancelled
+	m.saveTask(&cancelled)
+}
+
+// nextPending pops the most urgent pending event and, unless it is delayed
+// past now, records it as processed. The event stays in the pending store
+// until done is called for it.
+func (m *Manager) nextPending(now time.Time) (task.Event, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.Pending.Dequeue()
+	if !ok {
+		return task.Event{}, false
+	}
+	te := e.(task.Event)
+	if !now.Before(te.NotBefore) {
+		m.recordEvent(te)
+	}
+	return te, true
+}
+
+// hold puts back in the pending queue an event whose delay has not passed.
+// The pending store already holds it as it is, so it is not written again.
+func (m *Manager) hold(te task.Event) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Pending.Enqueue(te)
+}
+
+// done removes a dispatched event from the pending store.
+func (m *Manager) done(te task.Event) {
+	if err := m.Store.Pending.Delete(te.ID.String()); err != nil {
+		m.Logger.Error("Error removing pending event", slog.Any("ID", te.ID), slog.Any("err", err))
+	}
+}
+
+// recordEvent adds te to the event history; callers must hold m.mu. An event
+// recorded again, e.g. when its dispatch is retried, keeps its sequence number.
+func (m *Manager) recordEvent(te task.Event) {
+	if old, ok := m.EventDB[te.ID]; ok {
+		te.Seq = old.Seq
 	} else {
-		m.Logger.Info("No work in the queue")
+		m.eventSeq++
+		te.Seq = m.eventSeq
+	}
+	m.EventDB[te.ID] = &te
+	if err := m.Store.Events.Put(te.ID.String(), te); err != nil {
+		m.Logger.Error("Error persisting event", slog.Any("ID", te.ID), slog.Any("err", err))
 	}
+}
+
+// assign records that t has been placed on the worker named by t.Node.
+func (m *Manager) assign(t task.Task) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 
+	m.WorkerTaskMap[t.Node] = append(m.WorkerTaskMap[t.Node], t.ID)
+	m.TaskWorkerMap[t.ID] = t.Node
+	if err := m.Store.Assignments.Put(t.ID.String(), Assignment{TaskID: t.ID, Worker: t.Node}); err != nil {
+		m.Logger.Error("Error persisting assignment", slog.Any("ID", t.ID), slog.Any("err", err))
+	}
+	m.TaskDB[t.ID] = &t
+	m.saveTask(&t)
 }
+
+// AddTask queues te for dispatch and wakes the dispatch loop. A task seen for
+// the first time is recorded as pending until it is placed.
 func (m *Manager) AddTask(te task.Event) {
+	if te.Timestamp.IsZero() {
+		te.Timestamp = time.Now()
+	}
+	m.mu.Lock()
+	if _, ok := m.TaskDB[te.Task.ID]; !ok && te.State == task.Scheduled {
+		t := te.Task
+		t.State = task.Pending
+		m.TaskDB[t.ID] = &t
+		m.saveTask(&t)
+	}
+	m.mu.Unlock()
+	m.requeue(te)
+	select {
+	case m.wake <- struct{}{}:
+	default:
+	}
+}
+
+// requeue puts te back in the pending queue without waking the dispatch
+// loop, so that events that cannot be placed are retried on the next tick.
+func (m *Manager) requeue(te task.Event) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.Store.Pending.Put(te.ID.String(), te); err != nil {
+		m.Logger.Error("Error persisting pending event", slog.Any("ID", te.ID), slog.Any("err", err))
+	}
 	m.Pending.Enqueue(te)
 }
 
+// PendingCount returns the number of events waiting to be dispatched.
+func (m *Manager) PendingCount() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.Pending.Size()
+}
+
+// Wake fires when new work is added to the pending queue.
+func (m *Manager) Wake() <-chan struct{} {
+	return m.wake
+}
+
+// saveTask persists t; callers must hold m.mu.
+func (m *Manager) saveTask(t *task.Task) {
+	if err := m.Store.Tasks.Put(t.ID.String(), *t); err != nil {
+		m.Logger.Error("Error persisting task", slog.Any("ID", t.ID), slog.Any("err", err))
+	}
+}
+
+// GetTasks returns a snapshot of every task known to the manager.
 func (m *Manager) GetTasks() []*task.Task {
-	tasks, _ := lo.CoalesceSlice(slices.Collect(maps.Values(m.TaskDB)), []*task.Task{})
-	return tasks
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return lo.MapToSlice(m.TaskDB, func(_ uuid.UUID, t *task.Task) *task.Task {
+		c := *t
+		return &c
+	})
+}
 
+func (m *Manager) GetTask(id uuid.UUID) (*task.Task, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	t, ok := m.TaskDB[id]
+	if !ok {
+		return nil, false
+	}
+	c := *t
+	return &c, true
 }
 
-func (m *Manager) stopTask(worker string, taskID string) {
-	url := fmt.Sprintf("http://%s/tasks/%s", worker, taskID)
+// GetNodes returns a snapshot of the worker nodes, with the resources
+// allocated to the tasks scheduled or running on each of them.
+func (m *Manager) GetNodes() []*node.Node {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 
-	resp, err := m.Client.R().Delete(url)
+	nodes := make(map[string]*node.Node, len(m.WorkerNodes))
+	snapshot := lo.Map(m.WorkerNodes, func(n *node.Node, _ int) *node.Node {
+		c := *n
+		c.CPUAllocated, c.MemoryAllocated, c.DiskAllocated, c.TaskCount = 0, 0, 0, 0
+		c.Tasks, c.HostPorts = nil, nil
+		c.Taints = mergeTaints(n.Taints, m.taints[n.Name])
+		cordon, cordoned := m.cordons[n.Name]
+		c.Cordoned, c.Draining = cordoned, cordon.Drain
+		nodes[c.Name] = &c
+		return &c
+	})
+	for id, worker := range m.TaskWorkerMap {
+		t, ok := m.TaskDB[id]
+		n, found := nodes[worker]
+		if !ok || !found || (t.State != task.Scheduled && t.State != task.Running) {
+			continue
+		}
+		n.CPUAllocated += t.CPU
+		n.MemoryAllocated += t.Memory
+		n.DiskAllocated += t.Disk
+		n.TaskCount++
+		n.Tasks = append(n.Tasks, *t)
+		n.HostPorts = append(n.HostPorts, t.UsedHostPorts()...)
+	}
+	for _, n := range snapshot {
+		task.SortPorts(n.HostPorts)
+	}
+	return snapshot
+}
+
+// GetEvents returns the events processed by the manager in the order they
+// were recorded, restricted to a single task unless taskID is the zero UUID,
+// to events at or after since unless it is the zero time, and to events
+// recorded after the one numbered after.
+func (m *Manager) GetEvents(taskID uuid.UUID, since time.Time, after uint64) []*task.Event {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	events := lo.Filter(slices.Collect(maps.Values(m.EventDB)), func(e *task.Event, _ int) bool {
+		return (taskID == uuid.Nil || e.Task.ID == taskID) && !e.Timestamp.Before(since) && e.Seq > after
+	})
+	slices.SortFunc(events, func(a, b *task.Event) int {
+		return cmp.Compare(a.Seq, b.Seq)
+	})
+	return events
+}
 
+func (m *Manager) stopTask(ctx context.Context, worker string, taskID string) {
+	id, err := uuid.Parse(taskID)
 	if err != nil {
 		m.Logger.Error("Error stopping task", slog.Any("err", err))
 		return
 	}
 
-	if resp.StatusCode() != http.StatusNoContent {
-		m.Logger.Error("Error sending request stopping task", slog.Any("err", err))
+	err = m.workerClient(worker).StopTask(ctx, id)
+	if err != nil {
+		m.Logger.Error("Error stopping task", slog.Any("err", err))
 		return
 	}
 	m.Logger.Info("task has been scheduled to be stopped", slog.Any("task", taskID))
 }
+
+// workerClient returns the API client of a worker, sharing the manager's HTTP client.
+func (m *Manager) workerClient(worker string) *client.Client {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c, ok := m.clients[worker]
+	if !ok {
+		url := fmt.Sprintf("http://%s", worker)
+		if n := m.node(worker); n != nil && n.IP != "" {
+			url = n.IP
+		}
+		c = client.New(url, client.WithResty(m.Client))
+		m.clients[worker] = c
+	}
+	return c
+}
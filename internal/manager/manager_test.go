@@ -0,0 +1,271 @@
+package manager_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/httplog/v2"
+	"github.com/google/uuid"
+	"github.com/nduyhai/maestro/client"
+	"github.com/nduyhai/maestro/internal/loop"
+	"github.com/nduyhai/maestro/internal/manager"
+	"github.com/nduyhai/maestro/internal/scheduler"
+	"github.com/nduyhai/maestro/internal/task"
+	"github.com/nduyhai/maestro/internal/worker"
+	"resty.dev/v3"
+)
+
+var logger = httplog.NewLogger("test", httplog.Options{LogLevel: slog.LevelError, Writer: io.Discard})
+
+var testConfig = manager.Config{
+	NodeTimeout:       time.Minute,
+	RestartBackoff:    time.Second,
+	MaxRestartBackoff: time.Second,
+}
+
+// testWorker is a worker registered with the manager under test. Once down,
+// it stops sending heartbeats.
+type testWorker struct {
+	name string
+	down atomic.Bool
+}
+
+// startWorker runs a worker on the fake runtime, serves its API and returns
+// the URL it is reachable at.
+func startWorker(t *testing.T, g *loop.Group, name string) (*worker.Worker, string) {
+	t.Helper()
+	w := worker.NewWorker(task.NewFake(), worker.NewMemoryStore(), 4, logger)
+	w.Name = name
+	if err := w.Run(g, 10*time.Millisecond, 10*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	a := worker.NewAPI(w, logger)
+	r := chi.NewRouter()
+	r.Post("/tasks", a.StartTaskHandler)
+	r.Get("/tasks", a.GetTasksHandler)
+	r.Delete("/tasks/{taskID}", a.StopTaskHandler)
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return w, srv.URL
+}
+
+// startManager runs a manager on the in-memory store with workers registered
+// and sending heartbeats, and serves its task API.
+func startManager(t *testing.T, workers int, cfg manager.Config) (*manager.Manager, *client.Client, []*testWorker) {
+	t.Helper()
+	g := loop.NewGroup(logger)
+	rc := resty.New()
+	profiles, err := scheduler.NewProfiles("roundrobin", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := manager.NewManager(logger, rc, nil, manager.NewMemoryStore(), profiles, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registered := make([]*testWorker, workers)
+	for i := range workers {
+		w, url := startWorker(t, g, fmt.Sprintf("worker-%d", i))
+		name, err := m.Register(w.Registration(url))
+		if err != nil {
+			t.Fatal(err)
+		}
+		tw := &testWorker{name: name}
+		registered[i] = tw
+		err = g.Every("heartbeat-"+name, 20*time.Millisecond, nil, func(ctx context.Context) {
+			if tw.down.Load() {
+				return
+			}
+			if err := m.Heartbeat(ctx, name, w.Heartbeat()); err != nil {
+				t.Error(err)
+			}
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = m.RunLoops(g, manager.LoopConfig{
+		DispatchInterval:  10 * time.Millisecond,
+		ReconcileInterval: 20 * time.Millisecond,
+		HealthInterval:    20 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := manager.NewAPI(m, logger)
+	r := chi.NewRouter()
+	r.Route("/manager", func(r chi.Router) {
+		r.Post("/tasks", a.StartTaskHandler)
+		r.Get("/tasks", a.GetTasksHandler)
+		r.Delete("/tasks/{taskID}", a.StopTaskHandler)
+	})
+	srv := httptest.NewServer(r)
+
+	c := client.NewManager(srv.URL)
+	t.Cleanup(func() {
+		_ = c.Close()
+		srv.Close()
+		_ = g.Stop(context.Background())
+		_ = rc.Close()
+	})
+	return m, c, registered
+}
+
+// waitFor polls until every task is in state, or fails the test.
+func waitFor(t *testing.T, m *manager.Manager, ids []uuid.UUID, state task.State) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		pending := 0
+		for _, id := range ids {
+			if got, ok := m.GetTask(id); !ok || got.State != state {
+				pending++
+			}
+		}
+		if pending == 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d of %d tasks did not reach %s", pending, len(ids), state)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestManagerConcurrentSubmitStopList(t *testing.T) {
+	const n = 40
+	m, c, _ := startManager(t, 3, testConfig)
+	ctx := context.Background()
+
+	ids := make([]uuid.UUID, n)
+	for i := range ids {
+		ids[i] = uuid.New()
+	}
+
+	// Listers run throughout, against the API and the manager directly.
+	done := make(chan struct{})
+	var listers sync.WaitGroup
+	for range 4 {
+		listers.Add(1)
+		go func() {
+			defer listers.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				if _, err := c.ListTasks(ctx); err != nil {
+					t.Error(err)
+					return
+				}
+				_ = m.GetTasks()
+				_ = m.GetNodes()
+				_ = m.GetEvents(uuid.Nil, time.Time{}, 0)
+			}
+		}()
+	}
+
+	var wg sync.WaitGroup
+	for _, id := range ids {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			te := task.Event{ID: uuid.New(), State: task.Scheduled, Task: task.Task{ID: id, Name: id.String(), Image: "nginx", State: task.Scheduled}}
+			if _, err := c.SubmitTask(ctx, te); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	waitFor(t, m, ids, task.Running)
+
+	for _, id := range ids {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := c.StopTask(ctx, id); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	waitFor(t, m, ids, task.Completed)
+
+	close(done)
+	listers.Wait()
+
+	if got := len(m.GetTasks()); got != n {
+		t.Fatalf("got %d tasks, want %d", got, n)
+	}
+	placed := 0
+	for _, node := range m.GetNodes() {
+		placed += node.TaskCount
+	}
+	if placed != 0 {
+		t.Fatalf("%d stopped tasks still counted on nodes", placed)
+	}
+}
+
+func TestManagerConcurrentAddTask(t *testing.T) {
+	const n = 100
+	m, _, _ := startManager(t, 2, testConfig)
+
+	ids := make([]uuid.UUID, n)
+	var wg sync.WaitGroup
+	for i := range ids {
+		ids[i] = uuid.New()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m.AddTask(task.Event{ID: uuid.New(), State: task.Scheduled, Task: task.Task{ID: ids[i], Name: ids[i].String(), Image: "nginx", State: task.Scheduled}})
+			_ = m.GetTasks()
+			_ = m.PendingCount()
+		}()
+	}
+	wg.Wait()
+	waitFor(t, m, ids, task.Running)
+}
+
+func TestManagerReschedulesTasksOfLostNode(t *testing.T) {
+	cfg := testConfig
+	cfg.NodeTimeout = 50 * time.Millisecond
+	cfg.LostTimeout = 100 * time.Millisecond
+	m, _, workers := startManager(t, 2, cfg)
+
+	id := uuid.New()
+	m.AddTask(task.Event{ID: uuid.New(), State: task.Scheduled, Task: task.Task{ID: id, Name: "lost", Image: "nginx", State: task.Scheduled}})
+	waitFor(t, m, []uuid.UUID{id}, task.Running)
+
+	placed, _ := m.GetTask(id)
+	for _, w := range workers {
+		if w.name == placed.Node {
+			w.down.Store(true)
+		}
+	}
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		got, _ := m.GetTask(id)
+		if got.State == task.Running && got.Node != placed.Node {
+			if got.RestartCount != 0 {
+				t.Fatalf("rescheduling counted as %d restarts", got.RestartCount)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("task is %s on %s, want it running on another node than %s", got.State, got.Node, placed.Node)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,130 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/nduyhai/maestro/internal/node"
+	"github.com/nduyhai/maestro/internal/worker"
+)
+
+var ErrNodeNotFound = errors.New("node not found")
+
+// Register adds the worker described by r to the node registry, or updates
+// its node when it registers again. A static worker registering from the
+// address it was configured with keeps its name. It returns the name of the
+// node, which the worker uses for its heartbeats.
+func (m *Manager) Register(r worker.Registration) (string, error) {
+	if r.Name == "" || r.URL == "" {
+		return "", errors.New("registration needs a name and a URL")
+	}
+
+	m.mu.Lock()
+	n := m.node(r.Name)
+	if n == nil {
+		for _, candidate := range m.WorkerNodes {
+			if candidate.IP == r.URL {
+				n = candidate
+				break
+			}
+		}
+	}
+	if n == nil {
+		n = node.NewNode(r.Name, r.URL)
+		m.WorkerNodes = append(m.WorkerNodes, n)
+		m.Logger.Info("Worker registered", slog.String("worker", n.Name), slog.String("url", r.URL))
+	}
+	if n.IP != r.URL {
+		m.Logger.Info("Worker moved", slog.String("worker", n.Name), slog.String("from", n.IP), slog.String("to", r.URL))
+		n.IP = r.URL
+		delete(m.clients, n.Name)
+	}
+	m.registered[n.Name] = true
+	name := n.Name
+	m.mu.Unlock()
+
+	m.setNodeStatus(name, nodeReport{Stats: r.Stats, Info: &r.Info})
+	m.mu.Lock()
+	m.saveNode(m.node(name))
+	m.mu.Unlock()
+	return name, nil
+}
+
+// Heartbeat records that the named worker is alive and the state of its tasks.
+func (m *Manager) Heartbeat(ctx context.Context, name string, hb worker.Heartbeat) error {
+	if !m.isRegistered(name) {
+		return fmt.Errorf("%w: %s", ErrNodeNotFound, name)
+	}
+	m.setNodeStatus(name, nodeReport{Stats: hb.Stats})
+	m.syncTasks(ctx, name, hb.Tasks)
+	return nil
+}
+
+// lastContact returns when the manager last heard from n, counting nodes it
+// has not heard from since it started as seen at startup.
+func (m *Manager) lastContact(n *node.Node) time.Time {
+	if n.LastSeen.Before(m.started) {
+		return m.started
+	}
+	return n.LastSeen
+}
+
+// checkHeartbeat marks a registered node NotReady once it has gone without a
+// heartbeat for longer than the node timeout. Nodes restored from the store
+// get a full timeout from the start of the manager to check in.
+func (m *Manager) checkHeartbeat(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n := m.node(name)
+	if n == nil || m.Config.NodeTimeout <= 0 {
+		return
+	}
+	last := m.lastContact(n)
+	if n.Status == node.NotReady || n.Status == node.Lost || time.Since(last) <= m.Config.NodeTimeout {
+		return
+	}
+	m.Logger.Error("Worker missed its heartbeats", slog.String("worker", n.Name), slog.Time("lastSeen", n.LastSeen))
+	n.Status = node.NotReady
+}
+
+func (m *Manager) isRegistered(name string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.registered[name]
+}
+
+// restoreNode adds a node persisted by a previous registration. Its status is
+// unknown until the worker sends a heartbeat.
+func (m *Manager) restoreNode(n node.Node) {
+	n.Status = node.Unknown
+	if existing := m.node(n.Name); existing != nil {
+		existing.IP = n.IP
+	} else {
+		m.WorkerNodes = append(m.WorkerNodes, &n)
+	}
+	m.registered[n.Name] = true
+}
+
+// node returns the named node, or nil; callers must hold m.mu.
+func (m *Manager) node(name string) *node.Node {
+	for _, n := range m.WorkerNodes {
+		if n.Name == name {
+			return n
+		}
+	}
+	return nil
+}
+
+// saveNode persists a registered node; callers must hold m.mu.
+func (m *Manager) saveNode(n *node.Node) {
+	if n == nil {
+		return
+	}
+	if err := m.Store.Nodes.Put(n.Name, *n); err != nil {
+		m.Logger.Error("Error persisting node", slog.String("node", n.Name), slog.Any("err", err))
+	}
+}
@@ -0,0 +1,102 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nduyhai/maestro/internal/scheduler"
+	"github.com/nduyhai/maestro/internal/task"
+	"github.com/samber/lo"
+)
+
+// reschedule stops t on worker and queues it to be placed again, recording
+// reason as an event of the task.
+func (m *Manager) reschedule(ctx context.Context, worker string, t task.Task, reason string) {
+	if err := m.workerClient(worker).StopTask(ctx, t.ID); err != nil {
+		m.Logger.Error("Error stopping task", slog.Any("ID", t.ID), slog.String("worker", worker), slog.Any("err", err))
+	}
+	m.requeueTask(t, reason)
+}
+
+// requeueTask takes t off its worker and queues it to be placed again,
+// recording reason as an event of the task. It does not count as a restart.
+func (m *Manager) requeueTask(t task.Task, reason string) {
+	m.unassign(t.ID)
+
+	m.mu.Lock()
+	t.State = task.Pending
+	m.recordEvent(task.Event{ID: uuid.New(), State: task.Pending, Timestamp: time.Now().UTC(), Task: t, Message: reason})
+	m.mu.Unlock()
+
+	t.State = task.Scheduled
+	t.Node = ""
+	t.ContainerID = ""
+	t.HostPorts = nil
+	t.StartTime = time.Time{}
+	t.FinishTime = time.Time{}
+	m.AddTask(task.Event{ID: uuid.New(), State: task.Scheduled, Task: t})
+}
+
+// unassign forgets the worker of a task and marks it pending.
+func (m *Manager) unassign(id uuid.UUID) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.dropAssignment(id)
+	if t, ok := m.TaskDB[id]; ok {
+		pending := *t
+		pending.State = task.Pending
+		pending.Node = ""
+		pending.ContainerID = ""
+		pending.HostPorts = nil
+		pending.Health = ""
+		pending.StartTime = time.Time{}
+		m.TaskDB[id] = &pending
+		m.saveTask(&pending)
+	}
+}
+
+// dropAssignment forgets the worker of a task; callers must hold m.mu.
+func (m *Manager) dropAssignment(id uuid.UUID) {
+	if w, ok := m.TaskWorkerMap[id]; ok {
+		delete(m.TaskWorkerMap, id)
+		m.WorkerTaskMap[w] = lo.Without(m.WorkerTaskMap[w], id)
+	}
+	if err := m.Store.Assignments.Delete(id.String()); err != nil {
+		m.Logger.Error("Error removing assignment", slog.Any("ID", id), slog.Any("err", err))
+	}
+}
+
+// preempt stops tasks of a lower priority than t on the node chosen by the
+// scheduler so that t fits there. It reports whether any task was preempted.
+func (m *Manager) preempt(ctx context.Context, t task.Task) bool {
+	s, err := m.Profiles.For(t)
+	if err != nil {
+		return false
+	}
+	p := scheduler.Preempt(s, t, m.GetNodes())
+	if p == nil {
+		return false
+	}
+	names := lo.Map(p.Victims, func(v task.Task, _ int) string { return v.Name })
+	m.Logger.Info("Preempting tasks", slog.Any("ID", t.ID), slog.String("node", p.Node.Name), slog.Any("victims", names))
+
+	m.mu.Lock()
+	m.recordEvent(task.Event{
+		ID:        uuid.New(),
+		State:     task.Scheduled,
+		Timestamp: time.Now().UTC(),
+		Task:      t,
+		Message:   fmt.Sprintf("preempting %s on node %s", strings.Join(names, ", "), p.Node.Name),
+	})
+	m.mu.Unlock()
+
+	for _, v := range p.Victims {
+		m.reschedule(ctx, p.Node.Name, v, fmt.Sprintf("preempted by task %s (priority %d)", t.Name, t.Priority))
+	}
+	return true
+}
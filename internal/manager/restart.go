@@ -0,0 +1,66 @@
+package manager
+
+import (
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nduyhai/maestro/internal/task"
+)
+
+// restart applies the restart policy of t, which stopped running. A task that
+// is restarted goes back to the pending queue, to be placed on any node, once
+// a backoff that doubles with every restart has passed.
+func (m *Manager) restart(t task.Task) {
+	if !t.ShouldRestart() {
+		return
+	}
+	delay := m.restartDelay(t.RestartCount)
+	m.unassign(t.ID)
+
+	m.mu.Lock()
+	current, ok := m.TaskDB[t.ID]
+	if !ok || current.State != task.Pending {
+		m.mu.Unlock()
+		return
+	}
+	pending := *current
+	pending.RestartCount++
+	pending.FinishTime = time.Time{}
+	m.TaskDB[pending.ID] = &pending
+	m.saveTask(&pending)
+	now := time.Now().UTC()
+	m.recordEvent(task.Event{
+		ID:        uuid.New(),
+		State:     task.Pending,
+		Timestamp: now,
+		Task:      pending,
+		Message:   fmt.Sprintf("restart %d in %s: %s", pending.RestartCount, delay.Round(time.Millisecond), t.ExitReason),
+	})
+	m.mu.Unlock()
+
+	m.Logger.Info("Restarting task", slog.Any("ID", t.ID), slog.Int("restarts", pending.RestartCount), slog.Duration("delay", delay), slog.String("reason", t.ExitReason))
+	pending.State = task.Scheduled
+	m.AddTask(task.Event{ID: uuid.New(), State: task.Scheduled, Timestamp: now, NotBefore: now.Add(delay), Task: pending})
+}
+
+// restartDelay returns the backoff before the restart following the given
+// number of restarts: the base backoff doubled for each of them, up to the
+// maximum, of which a random half is taken off so that tasks that failed
+// together do not restart together.
+func (m *Manager) restartDelay(restarts int) time.Duration {
+	d := m.Config.RestartBackoff
+	if d <= 0 {
+		return 0
+	}
+	for range restarts {
+		if d >= m.Config.MaxRestartBackoff {
+			break
+		}
+		d *= 2
+	}
+	d = min(d, m.Config.MaxRestartBackoff)
+	return d/2 + rand.N(d/2+1)
+}
@@ -0,0 +1,81 @@
+package manager
+
+import (
+	"github.com/google/uuid"
+	"github.com/nduyhai/maestro/internal/node"
+	"github.com/nduyhai/maestro/internal/store"
+	"github.com/nduyhai/maestro/internal/task"
+	"go.etcd.io/bbolt"
+)
+
+type Assignment struct {
+	TaskID uuid.UUID
+	Worker string
+}
+
+// NodeTaints are the taints applied to a node through the manager API.
+type NodeTaints struct {
+	Node   string
+	Taints []task.Taint
+}
+
+// Store is the durable state of a manager.
+type Store struct {
+	Tasks       store.Store[task.Task]
+	Events      store.Store[task.Event]
+	Assignments store.Store[Assignment]
+	Pending     store.Store[task.Event]
+	Taints      store.Store[NodeTaints]
+	Groups      store.Store[task.Group]
+	Nodes       store.Store[node.Node]
+	Cordons     store.Store[Cordon]
+}
+
+func NewBoltStore(db *bbolt.DB) (*Store, error) {
+	tasks, err := store.NewBolt[task.Task](db, "tasks")
+	if err != nil {
+		return nil, err
+	}
+	events, err := store.NewBolt[task.Event](db, "events")
+	if err != nil {
+		return nil, err
+	}
+	assignments, err := store.NewBolt[Assignment](db, "assignments")
+	if err != nil {
+		return nil, err
+	}
+	pending, err := store.NewBolt[task.Event](db, "pending")
+	if err != nil {
+		return nil, err
+	}
+	taints, err := store.NewBolt[NodeTaints](db, "taints")
+	if err != nil {
+		return nil, err
+	}
+	groups, err := store.NewBolt[task.Group](db, "groups")
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := store.NewBolt[node.Node](db, "nodes")
+	if err != nil {
+		return nil, err
+	}
+	cordons, err := store.NewBolt[Cordon](db, "cordons")
+	if err != nil {
+		return nil, err
+	}
+	return &Store{Tasks: tasks, Events: events, Assignments: assignments, Pending: pending, Taints: taints, Groups: groups, Nodes: nodes, Cordons: cordons}, nil
+}
+
+func NewMemoryStore() *Store {
+	return &Store{
+		Tasks:       store.NewMemory[task.Task](),
+		Events:      store.NewMemory[task.Event](),
+		Assignments: store.NewMemory[Assignment](),
+		Pending:     store.NewMemory[task.Event](),
+		Taints:      store.NewMemory[NodeTaints](),
+		Groups:      store.NewMemory[task.Group](),
+		Nodes:       store.NewMemory[node.Node](),
+		Cordons:     store.NewMemory[Cordon](),
+	}
+}
@@ -0,0 +1,102 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/nduyhai/maestro/internal/task"
+	"github.com/samber/lo"
+)
+
+// AddTaint applies t to the named node, replacing any taint with the same key
+// and effect. Tasks that do not tolerate a NoExecute taint are evicted.
+func (m *Manager) AddTaint(name string, t task.Taint) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.hasNode(name) {
+		return fmt.Errorf("%w: %s", ErrNodeNotFound, name)
+	}
+	taints := slices.DeleteFunc(slices.Clone(m.taints[name]), func(old task.Taint) bool {
+		return old.Key == t.Key && old.Effect == t.Effect
+	})
+	m.setTaints(name, append(taints, t))
+	if t.Effect == task.NoExecute {
+		select {
+		case m.evictWake <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// RemoveTaint removes the taints with the given key applied to the named node
+// through the API. Taints reported by the worker itself are kept.
+func (m *Manager) RemoveTaint(name, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.hasNode(name) {
+		return fmt.Errorf("%w: %s", ErrNodeNotFound, name)
+	}
+	m.setTaints(name, slices.DeleteFunc(slices.Clone(m.taints[name]), func(t task.Taint) bool {
+		return t.Key == key
+	}))
+	return nil
+}
+
+// setTaints records and persists the API taints of a node; callers must hold m.mu.
+func (m *Manager) setTaints(name string, taints []task.Taint) {
+	if len(taints) == 0 {
+		delete(m.taints, name)
+		if err := m.Store.Taints.Delete(name); err != nil {
+			m.Logger.Error("Error removing taints", slog.String("node", name), slog.Any("err", err))
+		}
+		return
+	}
+	m.taints[name] = taints
+	if err := m.Store.Taints.Put(name, NodeTaints{Node: name, Taints: taints}); err != nil {
+		m.Logger.Error("Error persisting taints", slog.String("node", name), slog.Any("err", err))
+	}
+}
+
+// hasNode reports whether name is a known worker; callers must hold m.mu.
+func (m *Manager) hasNode(name string) bool {
+	return m.node(name) != nil
+}
+
+// EvictTasks stops the tasks placed on nodes with a NoExecute taint they do
+// not tolerate and queues them to be scheduled on another node.
+func (m *Manager) EvictTasks(ctx context.Context) {
+	for _, n := range m.GetNodes() {
+		for _, t := range n.Tasks {
+			taint, found := lo.Find(n.Taints, func(taint task.Taint) bool {
+				return taint.Effect == task.NoExecute && !t.Tolerates(taint)
+			})
+			if !found {
+				continue
+			}
+			m.Logger.Info("Evicting task", slog.Any("ID", t.ID), slog.String("node", n.Name), slog.String("taint", taint.String()))
+			m.reschedule(ctx, n.Name, t, fmt.Sprintf("evicted from node %s: taint %s not tolerated", n.Name, taint))
+		}
+	}
+}
+
+// EvictWake fires when a NoExecute taint is added.
+func (m *Manager) EvictWake() <-chan struct{} {
+	return m.evictWake
+}
+
+// mergeTaints returns the taints reported by a worker together with those
+// applied through the API, the latter winning for the same key and effect.
+func mergeTaints(reported, applied []task.Taint) []task.Taint {
+	merged := slices.Clone(applied)
+	for _, t := range reported {
+		if !slices.ContainsFunc(applied, func(a task.Taint) bool { return a.Key == t.Key && a.Effect == t.Effect }) {
+			merged = append(merged, t)
+		}
+	}
+	return merged
+}
@@ -1,17 +1,80 @@
 package node
 
+import (
+	"time"
+
+	"github.com/docker/go-connections/nat"
+	"github.com/nduyhai/maestro/internal/stats"
+	"github.com/nduyhai/maestro/internal/task"
+)
+
+type Status string
+
+// Topology keys of the failure domains reported by workers.
+const (
+	TopologyZone = "zone"
+	TopologyRack = "rack"
+)
+
+const (
+	Unknown  Status = "Unknown"
+	Ready    Status = "Ready"
+	NotReady Status = "NotReady"
+	// Lost nodes have been unreachable for so long that their tasks were
+	// taken off them.
+	Lost Status = "Lost"
+)
+
+// Node is a worker as seen by the manager. Capacity, Stats and the failure
+// domain (Zone and Rack) are reported by the worker; allocated amounts are the
+// sums requested by the tasks placed on it.
 type Node struct {
 	Name            string
 	IP              string
 	Cores           int
-	Memory          int
-	MemoryAllocated int
-	Disk            int
-	DiskAllocated   int
+	CPUAllocated    float64
+	Memory          int64
+	MemoryAllocated int64
+	Disk            int64
+	DiskAllocated   int64
 	Role            string
+	Zone            string
+	Rack            string
+	Labels          map[string]string
+	Taints          []task.Taint
 	TaskCount       int
+	// HostPorts are the host ports used by the tasks on the node.
+	HostPorts []nat.Port
+	Status    Status
+	// Cordoned nodes get no new tasks, and the tasks of Draining ones are
+	// being moved to other nodes.
+	Cordoned bool
+	Draining bool
+	LastSeen time.Time
+	Stats    *stats.Stats
+	// Tasks are the tasks scheduled or running on the node.
+	Tasks []task.Task `json:"-"`
+}
+
+// Topology returns the failure domain of n for key: its zone, its rack or,
+// for any other key, the value of that label. It is empty when unknown.
+func (n *Node) Topology(key string) string {
+	switch key {
+	case TopologyZone:
+		return n.Zone
+	case TopologyRack:
+		return n.Rack
+	default:
+		return n.Labels[key]
+	}
+}
+
+// Drain asks the manager to move the tasks off a node, no more than
+// MaxUnavailable of them at a time.
+type Drain struct {
+	MaxUnavailable int
 }
 
 func NewNode(name string, IP string) *Node {
-	return &Node{Name: name, IP: IP}
+	return &Node{Name: name, IP: IP, Status: Unknown}
 }
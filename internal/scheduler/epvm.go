@@ -0,0 +1,54 @@
+package scheduler
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/nduyhai/maestro/internal/node"
+	"github.com/nduyhai/maestro/internal/task"
+)
+
+// LIEB is the base of the E-PVM cost function. Raising it to the utilisation
+// of a resource makes every additional unit more expensive on busy nodes.
+const LIEB = 1.53960071783900203869
+
+// DefaultCPU is the CPU, in cores, a task without a CPU request is costed as.
+const DefaultCPU = 0.1
+
+// FitsMemoryInUse rejects nodes whose memory in use, as reported in their
+// stats, leaves no room for t. Nodes without stats are rejected as well.
+func FitsMemoryInUse(t task.Task, n *node.Node) error {
+	if n.Stats == nil {
+		return fmt.Errorf("stats not reported")
+	}
+	if free := n.Memory - n.Stats.MemoryUsed(); t.Memory > free {
+		return fmt.Errorf("insufficient memory in use: requested %d, free %d", t.Memory, free)
+	}
+	return nil
+}
+
+// EpvmCost implements the Enhanced Parallel Virtual Machine strategy: it is
+// the marginal cost of placing t on n, combining CPU load and memory
+// utilisation, so that tasks go where they add the least to the cost.
+func EpvmCost(t task.Task, n *node.Node) float64 {
+	cpu := t.CPU
+	if cpu <= 0 {
+		cpu = DefaultCPU
+	}
+	// Resources of tasks that were just placed may not show up in the
+	// stats yet, so the larger of used and allocated is taken as in use.
+	// A node that has not reported stats is costed by its allocations.
+	load, memory := n.CPUAllocated, float64(n.MemoryAllocated)
+	if n.Stats != nil {
+		load = math.Max(n.Stats.Load1(), load)
+		memory = float64(max(n.Stats.MemoryUsed(), n.MemoryAllocated))
+	}
+
+	cpuCost := cost(load+cpu, float64(n.Cores)) - cost(load, float64(n.Cores))
+	memCost := cost(memory+float64(t.Memory), float64(n.Memory)) - cost(memory, float64(n.Memory))
+	return cpuCost + memCost
+}
+
+func cost(used, capacity float64) float64 {
+	return math.Pow(LIEB, used/capacity)
+}
@@ -0,0 +1,146 @@
+package scheduler
+
+import (
+	"testing"
+
+	"github.com/nduyhai/maestro/internal/node"
+	"github.com/nduyhai/maestro/internal/stats"
+	"github.com/nduyhai/maestro/internal/task"
+	"github.com/shirou/gopsutil/v4/load"
+	"github.com/shirou/gopsutil/v4/mem"
+)
+
+// epvmNode returns a ready node reporting the given load and memory in use.
+func epvmNode(name string, cores int, load1 float64, usedGiB int64) *node.Node {
+	memory := int64(cores) * 4 * gib
+	return &node.Node{
+		Name:   name,
+		Status: node.Ready,
+		Cores:  cores,
+		Memory: memory,
+		Disk:   100 * gib,
+		Stats: &stats.Stats{
+			Load:   &load.AvgStat{Load1: load1},
+			Memory: &mem.VirtualMemoryStat{Total: uint64(memory), Used: uint64(usedGiB * gib)},
+		},
+	}
+}
+
+// withoutStats returns n as if it had not reported stats yet.
+func withoutStats(n *node.Node) *node.Node {
+	n.Stats = nil
+	return n
+}
+
+// withAllocated returns n with cpu cores and memory allocated to its tasks.
+func withAllocated(n *node.Node, cpu float64, memoryGiB int64) *node.Node {
+	n.CPUAllocated = cpu
+	n.MemoryAllocated = memoryGiB * gib
+	return n
+}
+
+func TestEpvmCost(t *testing.T) {
+	tk := task.Task{CPU: 1, Memory: gib}
+	tests := []struct {
+		name    string
+		a, b    *node.Node
+		cheaper string
+	}{
+		{
+			name:    "lower load",
+			a:       epvmNode("a", 4, 3, 2),
+			b:       epvmNode("b", 4, 0.5, 2),
+			cheaper: "b",
+		},
+		{
+			name:    "less memory in use",
+			a:       epvmNode("a", 4, 1, 12),
+			b:       epvmNode("b", 4, 1, 2),
+			cheaper: "b",
+		},
+		{
+			name:    "larger node at the same load",
+			a:       epvmNode("a", 4, 2, 2),
+			b:       epvmNode("b", 16, 2, 2),
+			cheaper: "b",
+		},
+		{
+			name:    "allocations not in the stats yet",
+			a:       withAllocated(epvmNode("a", 4, 0, 2), 3, 2),
+			b:       epvmNode("b", 4, 1, 2),
+			cheaper: "b",
+		},
+		{
+			name:    "no stats, busy by allocations",
+			a:       withAllocated(withoutStats(epvmNode("a", 4, 0, 0)), 3, 8),
+			b:       epvmNode("b", 4, 1, 2),
+			cheaper: "b",
+		},
+		{
+			name:    "no stats, idle",
+			a:       withoutStats(epvmNode("a", 4, 0, 0)),
+			b:       epvmNode("b", 4, 3, 8),
+			cheaper: "a",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			costA, costB := EpvmCost(tk, tt.a), EpvmCost(tk, tt.b)
+			got := "a"
+			if costB < costA {
+				got = "b"
+			}
+			if got != tt.cheaper {
+				t.Fatalf("cheaper node is %s (a %v, b %v), want %s", got, costA, costB, tt.cheaper)
+			}
+		})
+	}
+}
+
+func TestEpvmCostWithoutStats(t *testing.T) {
+	tk := task.Task{CPU: 1, Memory: gib}
+	n := &node.Node{Name: "node", Cores: 4, Memory: 16 * gib, CPUAllocated: 2, MemoryAllocated: 4 * gib}
+
+	got := EpvmCost(tk, n)
+	n.Stats = &stats.Stats{}
+	if want := EpvmCost(tk, n); got != want {
+		t.Fatalf("cost without stats = %v, want %v as with empty stats", got, want)
+	}
+}
+
+func TestEpvmProfile(t *testing.T) {
+	s := newScheduler(t, "epvm")
+	tk := task.Task{Name: "web", Image: "nginx", CPU: 1, Memory: gib, Disk: gib}
+	tests := []struct {
+		name  string
+		nodes []*node.Node
+		want  string
+	}{
+		{
+			name:  "least loaded",
+			nodes: []*node.Node{epvmNode("busy", 4, 3.5, 10), epvmNode("idle", 4, 0.2, 1), epvmNode("half", 4, 2, 8)},
+			want:  "idle",
+		},
+		{
+			name:  "memory in use leaves no room",
+			nodes: []*node.Node{epvmNode("full", 4, 0, 16), epvmNode("loaded", 4, 3, 4)},
+			want:  "loaded",
+		},
+		{
+			name:  "nodes without stats are skipped",
+			nodes: []*node.Node{withoutStats(epvmNode("new", 16, 0, 0)), epvmNode("loaded", 4, 3, 4)},
+			want:  "loaded",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			candidates := s.SelectCandidateNodes(tk, tt.nodes)
+			if len(candidates) == 0 {
+				t.Fatal("no candidate node")
+			}
+			if got := s.Pick(s.Score(tk, candidates), candidates); got.Name != tt.want {
+				t.Fatalf("picked %s, want %s", got.Name, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package scheduler
+
+import (
+	"errors"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/nduyhai/maestro/internal/node"
+	"github.com/nduyhai/maestro/internal/task"
+	"github.com/samber/lo"
+)
+
+// Snapshotter is implemented by schedulers whose Score changes their state.
+// Snapshot returns a copy that can be used without affecting real placements.
+type Snapshotter interface {
+	Snapshot() Scheduler
+}
+
+// NodeExplanation tells whether a node can take a task and, if so, its score.
+type NodeExplanation struct {
+	Node      string
+	Feasible  bool
+	Predicate string   `json:",omitempty"`
+	Reason    string   `json:",omitempty"`
+	Score     *float64 `json:",omitempty"`
+}
+
+// Explanation is the outcome of a scheduling dry run. Selected is empty when
+// no node can take the task; Preemption then holds the tasks that would be
+// stopped to make room, if preemption is enabled and possible.
+type Explanation struct {
+	Selected   string
+	Nodes      []NodeExplanation
+	Preemption *PreemptionExplanation `json:",omitempty"`
+}
+
+type PreemptionExplanation struct {
+	Node    string
+	Victims []Victim
+}
+
+type Victim struct {
+	ID       uuid.UUID
+	Name     string
+	Priority int
+}
+
+// ExplainPreemption describes p, or returns nil if p is nil.
+func ExplainPreemption(p *Preemption) *PreemptionExplanation {
+	if p == nil {
+		return nil
+	}
+	pe := &PreemptionExplanation{Node: p.Node.Name}
+	for _, v := range p.Victims {
+		pe.Victims = append(pe.Victims, Victim{ID: v.ID, Name: v.Name, Priority: v.Priority})
+	}
+	return pe
+}
+
+// Explain runs s against t and nodes without placing t.
+func Explain(s Scheduler, t task.Task, nodes []*node.Node) Explanation {
+	if ss, ok := s.(Snapshotter); ok {
+		s = ss.Snapshot()
+	}
+	nodes = withoutTask(t, nodes)
+
+	var reasons map[string]error
+	if e, ok := s.(Explainer); ok {
+		reasons = e.Explain(t, nodes)
+	}
+	candidates := s.SelectCandidateNodes(t, nodes)
+	var scores map[string]float64
+	var explanation Explanation
+	if len(candidates) > 0 {
+		scores = s.Score(t, candidates)
+		explanation.Selected = s.Pick(scores, candidates).Name
+	}
+
+	for _, n := range nodes {
+		ne := NodeExplanation{Node: n.Name}
+		if score, ok := scores[n.Name]; ok {
+			ne.Feasible = true
+			ne.Score = &score
+		} else if err, ok := reasons[n.Name]; ok {
+			ne.Reason = err.Error()
+			var fe *FilterError
+			if errors.As(err, &fe) {
+				ne.Predicate = fe.Predicate
+			}
+		} else {
+			ne.Reason = "not selected as a candidate"
+		}
+		explanation.Nodes = append(explanation.Nodes, ne)
+	}
+	return explanation
+}
+
+// withoutTask returns nodes with t taken off the node it is placed on, so
+// that a running task is explained as if it were being placed again.
+func withoutTask(t task.Task, nodes []*node.Node) []*node.Node {
+	nodes = slices.Clone(nodes)
+	for i, n := range nodes {
+		placed, ok := lo.Find(n.Tasks, func(other task.Task) bool { return other.ID == t.ID })
+		if !ok {
+			continue
+		}
+		trial := *n
+		trial.Tasks = slices.Clone(n.Tasks)
+		removeTask(&trial, placed)
+		nodes[i] = &trial
+	}
+	return nodes
+}
@@ -0,0 +1,186 @@
+package scheduler
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/nduyhai/maestro/internal/node"
+	"github.com/nduyhai/maestro/internal/task"
+)
+
+// DefaultFilters are the filters of a profile that does not list its own.
+var DefaultFilters = []string{"NodeReady", "ToleratesTaints", "MatchNodeSelector", "MatchAffinity", "MatchAntiAffinity", "FitsHostPorts", "TopologySpread", "FitsResources"}
+
+// taintWeight makes untolerated PreferNoSchedule taints outweigh any other
+// score, so such nodes are only picked when no other candidate is left.
+const taintWeight = 10
+
+// Profile describes a scheduler composed of registered plugins. Nodes must
+// pass every filter and the candidate with the lowest weighted sum of scores
+// is picked. Filters default to DefaultFilters.
+type Profile struct {
+	Name    string        `yaml:"name"`
+	Filters []string      `yaml:"filters"`
+	Scores  []ScoreWeight `yaml:"scores"`
+}
+
+type ScoreWeight struct {
+	Plugin string  `yaml:"plugin"`
+	Weight float64 `yaml:"weight"`
+}
+
+// builtinProfiles are the profiles available without any configuration.
+var builtinProfiles = []Profile{
+	{
+		Name:    "leastloaded",
+		Filters: DefaultFilters,
+		Scores: []ScoreWeight{
+			{Plugin: "LeastAllocated", Weight: 1},
+			{Plugin: "TopologySpread", Weight: 1},
+			{Plugin: "TaintToleration", Weight: taintWeight},
+		},
+	},
+	{
+		// binpack fills the nodes already in use before starting on empty
+		// ones, which can then be drained.
+		Name:    "binpack",
+		Filters: DefaultFilters,
+		Scores: []ScoreWeight{
+			{Plugin: "MostAllocated", Weight: 1},
+			{Plugin: "PreferOccupied", Weight: 1},
+			{Plugin: "TopologySpread", Weight: 1},
+			{Plugin: "TaintToleration", Weight: taintWeight},
+		},
+	},
+	{
+		Name:    "epvm",
+		Filters: append(slices.Clone(DefaultFilters), "FitsMemoryInUse"),
+		Scores: []ScoreWeight{
+			{Plugin: "Epvm", Weight: 1},
+			{Plugin: "TopologySpread", Weight: 1},
+			{Plugin: "TaintToleration", Weight: taintWeight},
+		},
+	},
+}
+
+type weightedScore struct {
+	ScorePlugin
+	Weight float64
+}
+
+// Framework is the scheduler built from a Profile.
+type Framework struct {
+	Name string
+
+	filters []Predicate
+	scores  []weightedScore
+}
+
+// NewFramework resolves the plugins named by p.
+func NewFramework(p Profile) (*Framework, error) {
+	if p.Name == "" {
+		return nil, fmt.Errorf("scheduler profile has no name")
+	}
+	if len(p.Scores) == 0 {
+		return nil, fmt.Errorf("scheduler profile %q has no score plugins", p.Name)
+	}
+	names := p.Filters
+	if len(names) == 0 {
+		names = DefaultFilters
+	}
+
+	f := &Framework{Name: p.Name}
+	for _, name := range names {
+		filter, err := lookupFilter(name)
+		if err != nil {
+			return nil, fmt.Errorf("scheduler profile %q: %w", p.Name, err)
+		}
+		f.filters = append(f.filters, filter)
+	}
+	for _, sw := range p.Scores {
+		score, err := lookupScore(sw.Plugin)
+		if err != nil {
+			return nil, fmt.Errorf("scheduler profile %q: %w", p.Name, err)
+		}
+		if sw.Weight <= 0 {
+			return nil, fmt.Errorf("scheduler profile %q: score plugin %s needs a positive weight", p.Name, sw.Plugin)
+		}
+		f.scores = append(f.scores, weightedScore{ScorePlugin: score, Weight: sw.Weight})
+	}
+	return f, nil
+}
+
+func (f *Framework) SelectCandidateNodes(t task.Task, nodes []*node.Node) []*node.Node {
+	candidates, _ := Filter(t, nodes, f.filters...)
+	return candidates
+}
+
+func (f *Framework) Explain(t task.Task, nodes []*node.Node) map[string]error {
+	_, reasons := Filter(t, nodes, f.filters...)
+	return reasons
+}
+
+// Score returns the weighted sum of the score plugins for each node.
+func (f *Framework) Score(t task.Task, nodes []*node.Node) map[string]float64 {
+	scores := make(map[string]float64)
+	for _, s := range f.scores {
+		score := s.Score
+		if s.Prepare != nil {
+			score = s.Prepare(t, nodes)
+		}
+		for _, n := range nodes {
+			scores[n.Name] += s.Weight * score(t, n)
+		}
+	}
+	return scores
+}
+
+func (f *Framework) Pick(scores map[string]float64, candidates []*node.Node) *node.Node {
+	return lowestScore(scores, candidates)
+}
+
+// Profiles holds the schedulers a task can choose from by setting
+// SchedulerProfile. Tasks that do not set it use the default one.
+type Profiles struct {
+	Default string
+
+	byName map[string]Scheduler
+}
+
+// NewProfiles builds the built-in schedulers and the given profiles, and
+// checks that defaultName is one of them.
+func NewProfiles(defaultName string, profiles []Profile) (*Profiles, error) {
+	p := &Profiles{Default: defaultName, byName: map[string]Scheduler{"roundrobin": &RoundRobin{Name: "roundrobin"}}}
+	for _, profile := range append(slices.Clone(builtinProfiles), profiles...) {
+		if _, ok := p.byName[profile.Name]; ok {
+			return nil, fmt.Errorf("duplicate scheduler profile %q", profile.Name)
+		}
+		f, err := NewFramework(profile)
+		if err != nil {
+			return nil, err
+		}
+		p.byName[profile.Name] = f
+	}
+	if _, ok := p.byName[defaultName]; !ok {
+		return nil, fmt.Errorf("unknown scheduler %q (known: %v)", defaultName, p.Names())
+	}
+	return p, nil
+}
+
+// For returns the scheduler of the profile named by t.SchedulerProfile.
+func (p *Profiles) For(t task.Task) (Scheduler, error) {
+	name := t.SchedulerProfile
+	if name == "" {
+		name = p.Default
+	}
+	s, ok := p.byName[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown scheduler profile %q", name)
+	}
+	return s, nil
+}
+
+func (p *Profiles) Names() []string {
+	return slices.Sorted(maps.Keys(p.byName))
+}
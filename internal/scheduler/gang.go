@@ -0,0 +1,47 @@
+package scheduler
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/nduyhai/maestro/internal/node"
+	"github.com/nduyhai/maestro/internal/task"
+)
+
+// PlaceGroup finds a node for every task so that all of them fit at the same
+// time, each task being placed with the ones before it already on their
+// nodes. It returns the node of each task, in order, or an error naming the
+// first task that fits nowhere. The returned nodes are copies of nodes.
+func PlaceGroup(p *Profiles, tasks []task.Task, nodes []*node.Node) ([]*node.Node, error) {
+	trial := make([]*node.Node, len(nodes))
+	for i, n := range nodes {
+		c := *n
+		c.Tasks = slices.Clone(n.Tasks)
+		trial[i] = &c
+	}
+
+	// Stateful schedulers are snapshotted once, so that the members advance
+	// the snapshot rather than the scheduler until the whole group fits.
+	snapshots := make(map[Scheduler]Scheduler)
+	placed := make([]*node.Node, len(tasks))
+	for i, t := range tasks {
+		s, err := p.For(t)
+		if err != nil {
+			return nil, err
+		}
+		if ss, ok := s.(Snapshotter); ok {
+			if _, ok := snapshots[s]; !ok {
+				snapshots[s] = ss.Snapshot()
+			}
+			s = snapshots[s]
+		}
+		candidates := s.SelectCandidateNodes(t, trial)
+		if len(candidates) == 0 {
+			return nil, fmt.Errorf("task %s: %w", t.Name, Unschedulable(s, t, trial))
+		}
+		n := s.Pick(s.Score(t, candidates), candidates)
+		addTask(n, t)
+		placed[i] = n
+	}
+	return placed, nil
+}
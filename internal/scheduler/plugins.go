@@ -0,0 +1,173 @@
+package scheduler
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/nduyhai/maestro/internal/node"
+	"github.com/nduyhai/maestro/internal/task"
+)
+
+// ScorePlugin is a named score of how well a node suits a task. As with the
+// schedulers, lower scores are better.
+type ScorePlugin struct {
+	Name  string
+	Score func(t task.Task, n *node.Node) float64
+	// Prepare, when set, builds Score from all the candidate nodes.
+	Prepare func(t task.Task, nodes []*node.Node) func(t task.Task, n *node.Node) float64
+}
+
+var (
+	pluginsMu sync.RWMutex
+	filters   = make(map[string]Predicate)
+	scores    = make(map[string]ScorePlugin)
+)
+
+func init() {
+	for _, p := range Placement {
+		RegisterFilter(p)
+	}
+	RegisterFilter(Predicate{Name: "FitsResources", Check: FitsResources})
+	RegisterFilter(Predicate{Name: "FitsMemoryInUse", Check: FitsMemoryInUse})
+
+	RegisterScore(ScorePlugin{Name: "LeastAllocated", Score: LeastAllocated})
+	RegisterScore(ScorePlugin{Name: "MostAllocated", Score: MostAllocated})
+	RegisterScore(ScorePlugin{Name: "PreferOccupied", Prepare: PreparePreferOccupied})
+	RegisterScore(ScorePlugin{Name: "Spread", Score: Spread})
+	RegisterScore(ScorePlugin{Name: "TopologySpread", Prepare: PrepareTopologySpreadScore})
+	RegisterScore(ScorePlugin{Name: "ImageLocality", Score: ImageLocality})
+	RegisterScore(ScorePlugin{Name: "TaintToleration", Score: TaintToleration})
+	RegisterScore(ScorePlugin{Name: "Epvm", Score: EpvmCost})
+}
+
+// RegisterFilter makes p available to profiles under its name, replacing any
+// filter registered under the same name.
+func RegisterFilter(p Predicate) {
+	pluginsMu.Lock()
+	defer pluginsMu.Unlock()
+	filters[p.Name] = p
+}
+
+// RegisterScore makes p available to profiles under its name, replacing any
+// score plugin registered under the same name.
+func RegisterScore(p ScorePlugin) {
+	pluginsMu.Lock()
+	defer pluginsMu.Unlock()
+	scores[p.Name] = p
+}
+
+func lookupFilter(name string) (Predicate, error) {
+	pluginsMu.RLock()
+	defer pluginsMu.RUnlock()
+	p, ok := filters[name]
+	if !ok {
+		return Predicate{}, fmt.Errorf("unknown filter plugin %q (known: %v)", name, slices.Sorted(maps.Keys(filters)))
+	}
+	return p, nil
+}
+
+func lookupScore(name string) (ScorePlugin, error) {
+	pluginsMu.RLock()
+	defer pluginsMu.RUnlock()
+	p, ok := scores[name]
+	if !ok {
+		return ScorePlugin{}, fmt.Errorf("unknown score plugin %q (known: %v)", name, slices.Sorted(maps.Keys(scores)))
+	}
+	return p, nil
+}
+
+// allocatedShare returns the average share of CPU, memory and disk of n that
+// would be allocated once t is placed on it. Nodes that have not reported
+// their capacity count as full.
+func allocatedShare(t task.Task, n *node.Node) float64 {
+	if n.Cores == 0 || n.Memory == 0 || n.Disk == 0 {
+		return 1
+	}
+	cpu := (n.CPUAllocated + t.CPU) / float64(n.Cores)
+	memory := float64(n.MemoryAllocated+t.Memory) / float64(n.Memory)
+	disk := float64(n.DiskAllocated+t.Disk) / float64(n.Disk)
+	return (cpu + memory + disk) / 3
+}
+
+// LeastAllocated prefers the nodes with the most room left for t.
+func LeastAllocated(t task.Task, n *node.Node) float64 {
+	return allocatedShare(t, n)
+}
+
+// MostAllocated prefers the nodes with the least room left for t, packing
+// tasks onto as few nodes as possible.
+func MostAllocated(t task.Task, n *node.Node) float64 {
+	return 1 - allocatedShare(t, n)
+}
+
+// PreparePreferOccupied scores nodes already running tasks 0 and empty nodes
+// between 1 and 2, so that empty nodes are only used when no other node can
+// take t. Among empty nodes the largest scores lowest: opening it leaves the
+// most room for the tasks that follow.
+func PreparePreferOccupied(t task.Task, nodes []*node.Node) func(task.Task, *node.Node) float64 {
+	var cores int
+	var memory int64
+	for _, n := range nodes {
+		cores = max(cores, n.Cores)
+		memory = max(memory, n.Memory)
+	}
+	return func(t task.Task, n *node.Node) float64 {
+		for _, other := range n.Tasks {
+			if other.ID != t.ID {
+				return 0
+			}
+		}
+		if cores == 0 || memory == 0 {
+			return 1
+		}
+		size := (float64(n.Cores)/float64(cores) + float64(n.Memory)/float64(memory)) / 2
+		return 2 - size
+	}
+}
+
+// Spread prefers the nodes running the fewest tasks that carry all of t's
+// labels, or the fewest tasks at all when t has no labels. The score grows
+// from 0 towards 1 with the number of such tasks.
+func Spread(t task.Task, n *node.Node) float64 {
+	var count int
+	for _, other := range n.Tasks {
+		if other.ID != t.ID && hasLabels(other.Labels, t.Labels) {
+			count++
+		}
+	}
+	return float64(count) / float64(count+1)
+}
+
+func hasLabels(labels, want map[string]string) bool {
+	for k, v := range want {
+		if got, ok := labels[k]; !ok || got != v {
+			return false
+		}
+	}
+	return true
+}
+
+// ImageLocality prefers the nodes already running a task from t's image, as
+// they are likely to have the image pulled.
+func ImageLocality(t task.Task, n *node.Node) float64 {
+	for _, other := range n.Tasks {
+		if other.ID != t.ID && other.Image == t.Image {
+			return 0
+		}
+	}
+	return 1
+}
+
+// TaintToleration counts the PreferNoSchedule taints of n that t does not
+// tolerate.
+func TaintToleration(t task.Task, n *node.Node) float64 {
+	var count float64
+	for _, taint := range n.Taints {
+		if taint.Effect == task.PreferNoSchedule && !t.Tolerates(taint) {
+			count++
+		}
+	}
+	return count
+}
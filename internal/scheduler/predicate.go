@@ -0,0 +1,198 @@
+package scheduler
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/nduyhai/maestro/internal/node"
+	"github.com/nduyhai/maestro/internal/task"
+	"github.com/samber/lo"
+)
+
+// Predicate is a named check of whether a task can be placed on a node. Check
+// returns an error saying why t cannot be placed on n, or nil if it can.
+type Predicate struct {
+	Name  string
+	Check func(t task.Task, n *node.Node) error
+	// Prepare, when set, builds Check from all the nodes being filtered, for
+	// predicates that compare a node with the others.
+	Prepare func(t task.Task, nodes []*node.Node) func(t task.Task, n *node.Node) error
+}
+
+// Placement holds the predicates every scheduler applies before its own.
+var Placement = []Predicate{
+	{Name: "NodeReady", Check: NodeReady},
+	{Name: "ToleratesTaints", Check: ToleratesTaints},
+	{Name: "MatchNodeSelector", Check: MatchNodeSelector},
+	{Name: "MatchAffinity", Check: MatchAffinity},
+	{Name: "MatchAntiAffinity", Check: MatchAntiAffinity},
+	{Name: "FitsHostPorts", Check: FitsHostPorts},
+	{Name: "TopologySpread", Prepare: PrepareTopologySpread},
+}
+
+// FilterError is the reason a predicate rejected a node.
+type FilterError struct {
+	Predicate string
+	Err       error
+}
+
+func (e *FilterError) Error() string { return e.Err.Error() }
+func (e *FilterError) Unwrap() error { return e.Err }
+
+// Explainer is implemented by schedulers that can say why nodes were rejected.
+type Explainer interface {
+	// Explain returns the reason each rejected node cannot run t, by node name.
+	Explain(t task.Task, nodes []*node.Node) map[string]error
+}
+
+// Filter returns the nodes that pass every predicate and, for the others, the
+// FilterError of the first predicate that rejected them.
+func Filter(t task.Task, nodes []*node.Node, predicates ...Predicate) ([]*node.Node, map[string]error) {
+	predicates = slices.Clone(predicates)
+	for i, p := range predicates {
+		if p.Prepare != nil {
+			predicates[i].Check = p.Prepare(t, nodes)
+		}
+	}
+
+	var candidates []*node.Node
+	reasons := make(map[string]error)
+	for _, n := range nodes {
+		if err := check(t, n, predicates); err != nil {
+			reasons[n.Name] = err
+			continue
+		}
+		candidates = append(candidates, n)
+	}
+	return candidates, reasons
+}
+
+func check(t task.Task, n *node.Node, predicates []Predicate) error {
+	for _, p := range predicates {
+		if err := p.Check(t, n); err != nil {
+			return &FilterError{Predicate: p.Name, Err: err}
+		}
+	}
+	return nil
+}
+
+func NodeReady(_ task.Task, n *node.Node) error {
+	if n.Status == node.NotReady || n.Status == node.Lost {
+		return fmt.Errorf("node is %s", n.Status)
+	}
+	if n.Cordoned {
+		return fmt.Errorf("node is cordoned")
+	}
+	return nil
+}
+
+// ToleratesTaints rejects nodes with a NoSchedule or NoExecute taint that t
+// does not tolerate.
+func ToleratesTaints(t task.Task, n *node.Node) error {
+	for _, taint := range n.Taints {
+		if taint.Effect != task.PreferNoSchedule && !t.Tolerates(taint) {
+			return fmt.Errorf("taint %s not tolerated", taint)
+		}
+	}
+	return nil
+}
+
+// taintPenalty is added to the score of a node for the PreferNoSchedule taints
+// t does not tolerate.
+func taintPenalty(t task.Task, n *node.Node) float64 {
+	return taintWeight * TaintToleration(t, n)
+}
+
+func MatchNodeSelector(t task.Task, n *node.Node) error {
+	for _, k := range slices.Sorted(maps.Keys(t.NodeSelector)) {
+		if v, ok := n.Labels[k]; !ok || v != t.NodeSelector[k] {
+			return fmt.Errorf("node selector %s=%s does not match", k, t.NodeSelector[k])
+		}
+	}
+	return nil
+}
+
+func MatchAffinity(t task.Task, n *node.Node) error {
+	for _, sel := range t.Affinity {
+		if !lo.ContainsBy(n.Tasks, func(other task.Task) bool { return other.ID != t.ID && sel.Matches(other.Labels) }) {
+			return fmt.Errorf("no task matching affinity %s", sel)
+		}
+	}
+	return nil
+}
+
+func MatchAntiAffinity(t task.Task, n *node.Node) error {
+	for _, sel := range t.AntiAffinity {
+		for _, other := range n.Tasks {
+			if other.ID != t.ID && sel.Matches(other.Labels) {
+				return fmt.Errorf("task %s matches anti-affinity %s", other.Name, sel)
+			}
+		}
+	}
+	return nil
+}
+
+// FitsHostPorts rejects nodes where a host port t binds is used by another task.
+func FitsHostPorts(t task.Task, n *node.Node) error {
+	requested, err := t.HostPortRequests()
+	if err != nil {
+		return err
+	}
+	for _, other := range n.Tasks {
+		if other.ID == t.ID {
+			continue
+		}
+		used := other.UsedHostPorts()
+		for _, p := range requested {
+			if slices.Contains(used, p) {
+				return fmt.Errorf("host port %s used by task %s", p, other.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// FitsResources rejects nodes without enough unallocated CPU, memory or disk.
+// Nodes that have not reported their capacity yet are rejected as well.
+func FitsResources(t task.Task, n *node.Node) error {
+	switch {
+	case n.Cores == 0 || n.Memory == 0 || n.Disk == 0:
+		return fmt.Errorf("capacity not reported")
+	case n.CPUAllocated+t.CPU > float64(n.Cores):
+		return fmt.Errorf("insufficient cpu: requested %g, free %g", t.CPU, float64(n.Cores)-n.CPUAllocated)
+	case n.MemoryAllocated+t.Memory > n.Memory:
+		return fmt.Errorf("insufficient memory: requested %d, free %d", t.Memory, n.Memory-n.MemoryAllocated)
+	case n.DiskAllocated+t.Disk > n.Disk:
+		return fmt.Errorf("insufficient disk: requested %d, free %d", t.Disk, n.Disk-n.DiskAllocated)
+	}
+	return nil
+}
+
+// UnschedulableError reports why no node could take a task.
+type UnschedulableError struct {
+	TaskID  uuid.UUID
+	Reasons map[string]error
+}
+
+func (e *UnschedulableError) Error() string {
+	if len(e.Reasons) == 0 {
+		return fmt.Sprintf("no nodes available for task %v", e.TaskID)
+	}
+	parts := make([]string, 0, len(e.Reasons))
+	for _, name := range slices.Sorted(maps.Keys(e.Reasons)) {
+		parts = append(parts, fmt.Sprintf("%s: %v", name, e.Reasons[name]))
+	}
+	return fmt.Sprintf("no node matches task %v: %s", e.TaskID, strings.Join(parts, "; "))
+}
+
+// Unschedulable builds the error returned when s finds no candidate for t.
+func Unschedulable(s Scheduler, t task.Task, nodes []*node.Node) *UnschedulableError {
+	err := &UnschedulableError{TaskID: t.ID, Reasons: make(map[string]error)}
+	if e, ok := s.(Explainer); ok {
+		err.Reasons = e.Explain(t, nodes)
+	}
+	return err
+}
@@ -0,0 +1,99 @@
+package scheduler
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/nduyhai/maestro/internal/node"
+	"github.com/nduyhai/maestro/internal/task"
+)
+
+// Preemption is a node that can take a task once the victims are stopped.
+type Preemption struct {
+	Node    *node.Node
+	Victims []task.Task
+}
+
+// Preempt looks for a node where s would accept t after stopping tasks of a
+// lower priority. Lower priorities and, among equal ones, the most recently
+// started tasks are chosen first, and victims whose removal turns out not to
+// be needed are spared. Nodes whose worst victim has the lowest priority are
+// preferred, then those with the fewest victims. It returns nil when no node
+// can be freed up.
+func Preempt(s Scheduler, t task.Task, nodes []*node.Node) *Preemption {
+	var best *Preemption
+	for _, n := range nodes {
+		victims := selectVictims(s, t, n, nodes)
+		if len(victims) == 0 {
+			continue
+		}
+		if best == nil || betterVictims(victims, best.Victims) {
+			best = &Preemption{Node: n, Victims: victims}
+		}
+	}
+	return best
+}
+
+// selectVictims picks the tasks to stop on n for t to fit there. Filters are
+// run against every node, with n in its trial state, so that predicates such
+// as TopologySpread see the whole cluster.
+func selectVictims(s Scheduler, t task.Task, n *node.Node, nodes []*node.Node) []task.Task {
+	candidates := slices.DeleteFunc(slices.Clone(n.Tasks), func(other task.Task) bool {
+		return other.Priority >= t.Priority
+	})
+	slices.SortStableFunc(candidates, func(a, b task.Task) int {
+		return cmp.Or(cmp.Compare(a.Priority, b.Priority), b.StartTime.Compare(a.StartTime))
+	})
+
+	trial := *n
+	trial.Tasks = slices.Clone(n.Tasks)
+	cluster := slices.Clone(nodes)
+	cluster[slices.Index(nodes, n)] = &trial
+	fits := func() bool { return slices.Contains(s.SelectCandidateNodes(t, cluster), &trial) }
+
+	var victims []task.Task
+	for _, v := range candidates {
+		if fits() {
+			break
+		}
+		removeTask(&trial, v)
+		victims = append(victims, v)
+	}
+	if len(victims) == 0 || !fits() {
+		return nil
+	}
+
+	// Give back the victims, highest priority first, that t can live with.
+	for i := len(victims) - 1; i >= 0; i-- {
+		addTask(&trial, victims[i])
+		if fits() {
+			victims = slices.Delete(victims, i, i+1)
+			continue
+		}
+		removeTask(&trial, victims[i])
+	}
+	return victims
+}
+
+func betterVictims(a, b []task.Task) bool {
+	maxPriority := func(victims []task.Task) int {
+		return slices.MaxFunc(victims, func(x, y task.Task) int { return cmp.Compare(x.Priority, y.Priority) }).Priority
+	}
+	return cmp.Or(cmp.Compare(maxPriority(a), maxPriority(b)), cmp.Compare(len(a), len(b))) < 0
+}
+
+func removeTask(n *node.Node, t task.Task) {
+	n.Tasks = slices.DeleteFunc(n.Tasks, func(other task.Task) bool { return other.ID == t.ID })
+	n.CPUAllocated -= t.CPU
+	n.MemoryAllocated -= t.Memory
+	n.DiskAllocated -= t.Disk
+	n.TaskCount--
+}
+
+func addTask(n *node.Node, t task.Task) {
+	n.Tasks = append(n.Tasks, t)
+	n.CPUAllocated += t.CPU
+	n.MemoryAllocated += t.Memory
+	n.DiskAllocated += t.Disk
+	n.TaskCount++
+}
@@ -0,0 +1,48 @@
+package scheduler
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/nduyhai/maestro/internal/node"
+	"github.com/nduyhai/maestro/internal/task"
+)
+
+func TestPreemptHonoursTopologySpread(t *testing.T) {
+	web := map[string]string{"app": "web"}
+	crowded := &node.Node{Name: "a", Status: node.Ready, Zone: "zone-1", Cores: 2, Memory: 4 * gib, Disk: 10 * gib}
+	addTask(crowded, task.Task{ID: uuid.New(), Labels: web, Priority: 10, CPU: 0.5})
+	addTask(crowded, task.Task{ID: uuid.New(), Labels: web, Priority: 10, CPU: 0.5})
+	addTask(crowded, task.Task{ID: uuid.New(), Priority: 0, CPU: 1})
+	empty := &node.Node{Name: "b", Status: node.Ready, Zone: "zone-2", Cores: 1, Memory: 4 * gib, Disk: 10 * gib}
+	addTask(empty, task.Task{ID: uuid.New(), Priority: 100, CPU: 1})
+
+	// Zone 1 already holds two more web tasks than zone 2, so the scheduler
+	// rejects node a however much room is made there.
+	pending := task.Task{
+		ID:             uuid.New(),
+		Labels:         web,
+		Priority:       10,
+		CPU:            1,
+		TopologySpread: []task.TopologySpread{{TopologyKey: node.TopologyZone, MaxSkew: 1}},
+	}
+	if p := Preempt(newScheduler(t, "leastloaded"), pending, []*node.Node{crowded, empty}); p != nil {
+		t.Fatalf("preempted %d tasks on node %s, which topology spread rejects", len(p.Victims), p.Node.Name)
+	}
+}
+
+func TestPreemptStopsLowestPriority(t *testing.T) {
+	n := &node.Node{Name: "a", Status: node.Ready, Cores: 2, Memory: 4 * gib, Disk: 10 * gib}
+	low := task.Task{ID: uuid.New(), Priority: 0, CPU: 1}
+	high := task.Task{ID: uuid.New(), Priority: 5, CPU: 1}
+	addTask(n, low)
+	addTask(n, high)
+
+	p := Preempt(newScheduler(t, "leastloaded"), task.Task{ID: uuid.New(), Priority: 10, CPU: 1}, []*node.Node{n})
+	if p == nil {
+		t.Fatal("no preemption found")
+	}
+	if len(p.Victims) != 1 || p.Victims[0].ID != low.ID {
+		t.Fatalf("got victims %v, want only the lowest priority task", p.Victims)
+	}
+}
@@ -1,6 +1,8 @@
 package scheduler
 
 import (
+	"sync"
+
 	"github.com/nduyhai/maestro/internal/node"
 	"github.com/nduyhai/maestro/internal/task"
 )
@@ -14,12 +16,29 @@ type Scheduler interface {
 type RoundRobin struct {
 	Name       string
 	LastWorker int
+
+	mu sync.Mutex
 }
 
 func (r *RoundRobin) SelectCandidateNodes(t task.Task, nodes []*node.Node) []*node.Node {
-	return nodes
+	candidates, _ := Filter(t, nodes, Placement...)
+	return candidates
+}
+
+func (r *RoundRobin) Snapshot() Scheduler {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return &RoundRobin{Name: r.Name, LastWorker: r.LastWorker}
+}
+
+func (r *RoundRobin) Explain(t task.Task, nodes []*node.Node) map[string]error {
+	_, reasons := Filter(t, nodes, Placement...)
+	return reasons
 }
 func (r *RoundRobin) Score(t task.Task, nodes []*node.Node) map[string]float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	nodeScores := make(map[string]float64)
 	var newWorker int
 	if r.LastWorker+1 < len(nodes) {
@@ -36,12 +55,18 @@ func (r *RoundRobin) Score(t task.Task, nodes []*node.Node) map[string]float64 {
 		} else {
 			nodeScores[node.Name] = 1.0
 		}
+		nodeScores[node.Name] += taintPenalty(t, node)
 	}
 
 	return nodeScores
 }
 
 func (r *RoundRobin) Pick(scores map[string]float64, candidates []*node.Node) *node.Node {
+	return lowestScore(scores, candidates)
+}
+
+// lowestScore returns the first candidate with the lowest score.
+func lowestScore(scores map[string]float64, candidates []*node.Node) *node.Node {
 	var bestNode *node.Node
 	var lowestScore float64
 	for idx, node := range candidates {
@@ -0,0 +1,187 @@
+package scheduler
+
+import (
+	"cmp"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/nduyhai/maestro/internal/node"
+	"github.com/nduyhai/maestro/internal/task"
+)
+
+const gib = 1 << 30
+
+var (
+	benchProfiles = []string{"binpack", "leastloaded"}
+	benchSizes    = []int{100, 500}
+)
+
+// syntheticCluster returns size ready nodes of mixed capacity spread over
+// three zones. The same seed always yields the same cluster.
+func syntheticCluster(size int, seed uint64) []*node.Node {
+	r := rand.New(rand.NewPCG(seed, 0))
+	nodes := make([]*node.Node, size)
+	for i := range nodes {
+		cores := 4 << r.IntN(3) // 4, 8 or 16
+		nodes[i] = &node.Node{
+			Name:   fmt.Sprintf("node-%03d", i),
+			Status: node.Ready,
+			Cores:  cores,
+			Memory: int64(cores) * 4 * gib,
+			Disk:   200 * gib,
+			Zone:   fmt.Sprintf("zone-%d", i%3),
+		}
+	}
+	return nodes
+}
+
+// syntheticTasks returns count tasks asking for between half a core and two
+// cores, with memory to match.
+func syntheticTasks(count int, seed uint64) []task.Task {
+	r := rand.New(rand.NewPCG(seed, 1))
+	tasks := make([]task.Task, count)
+	for i := range tasks {
+		cpu := float64(1+r.IntN(4)) / 2
+		tasks[i] = task.Task{
+			ID:     uuid.New(),
+			Name:   fmt.Sprintf("task-%d", i),
+			Image:  "nginx",
+			CPU:    cpu,
+			Memory: int64(cpu * 2 * gib),
+			Disk:   gib,
+		}
+	}
+	return tasks
+}
+
+func newScheduler(tb testing.TB, profile string) Scheduler {
+	tb.Helper()
+	p, err := NewProfiles(profile, nil)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	s, err := p.For(task.Task{})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return s
+}
+
+// place runs s for each task in turn and records the placements on the
+// nodes. It returns the number of tasks that fit nowhere.
+func place(s Scheduler, tasks []task.Task, nodes []*node.Node) int {
+	unplaced := 0
+	for _, t := range tasks {
+		candidates := s.SelectCandidateNodes(t, nodes)
+		if len(candidates) == 0 {
+			unplaced++
+			continue
+		}
+		addTask(s.Pick(s.Score(t, candidates), candidates), t)
+	}
+	return unplaced
+}
+
+func activeNodes(nodes []*node.Node) int {
+	active := 0
+	for _, n := range nodes {
+		if n.TaskCount > 0 {
+			active++
+		}
+	}
+	return active
+}
+
+// minActiveNodes returns the fewest nodes whose cores cover the CPU the tasks
+// ask for, taking the largest first: no placement can use fewer.
+func minActiveNodes(tasks []task.Task, nodes []*node.Node) int {
+	var cpu float64
+	for _, t := range tasks {
+		cpu += t.CPU
+	}
+	cores := make([]int, len(nodes))
+	for i, n := range nodes {
+		cores[i] = n.Cores
+	}
+	slices.SortFunc(cores, func(a, b int) int { return cmp.Compare(b, a) })
+	count := 0
+	for covered := 0.0; covered < cpu && count < len(cores); count++ {
+		covered += float64(cores[count])
+	}
+	return count
+}
+
+// maxActiveNodes bounds the nodes binpack may use to place tasks, within 10%
+// of the fewest possible.
+func maxActiveNodes(tasks []task.Task, nodes []*node.Node) float64 {
+	return float64(minActiveNodes(tasks, nodes)) * 1.1
+}
+
+func TestBinpackFillsFewNodes(t *testing.T) {
+	s := newScheduler(t, "binpack")
+	nodes := syntheticCluster(100, 1)
+	tasks := syntheticTasks(300, 2)
+
+	if unplaced := place(s, tasks, nodes); unplaced != 0 {
+		t.Fatalf("%d tasks not placed", unplaced)
+	}
+	if active, bound := activeNodes(nodes), maxActiveNodes(tasks, syntheticCluster(100, 1)); float64(active) > bound {
+		t.Fatalf("binpack uses %d nodes, want at most %.1f", active, bound)
+	}
+}
+
+// BenchmarkSelectCandidateNodes filters a cluster with about 40% of its CPU
+// already allocated.
+func BenchmarkSelectCandidateNodes(b *testing.B) {
+	for _, profile := range benchProfiles {
+		for _, size := range benchSizes {
+			b.Run(fmt.Sprintf("%s/nodes=%d", profile, size), func(b *testing.B) {
+				s := newScheduler(b, profile)
+				nodes := syntheticCluster(size, 1)
+				place(s, syntheticTasks(size*3, 2), nodes)
+				t := syntheticTasks(1, 3)[0]
+
+				b.ReportAllocs()
+				for b.Loop() {
+					s.SelectCandidateNodes(t, nodes)
+				}
+				b.ReportMetric(float64(size), "nodes")
+			})
+		}
+	}
+}
+
+// BenchmarkPlacement places enough tasks to allocate about 40% of the CPU of
+// the cluster and reports how many nodes end up running tasks: the fewer, the
+// more nodes are left empty to be drained. It fails when binpack uses more
+// nodes than maxActiveNodes.
+func BenchmarkPlacement(b *testing.B) {
+	for _, profile := range benchProfiles {
+		for _, size := range benchSizes {
+			b.Run(fmt.Sprintf("%s/nodes=%d", profile, size), func(b *testing.B) {
+				s := newScheduler(b, profile)
+				tasks := syntheticTasks(size*3, 2)
+				var active, unplaced, runs int
+
+				for b.Loop() {
+					b.StopTimer()
+					nodes := syntheticCluster(size, 1)
+					b.StartTimer()
+					unplaced += place(s, tasks, nodes)
+					active += activeNodes(nodes)
+					runs++
+				}
+				perRun := float64(active) / float64(runs)
+				b.ReportMetric(float64(size), "nodes")
+				b.ReportMetric(perRun, "active-nodes")
+				b.ReportMetric(float64(unplaced)/float64(runs), "unplaced")
+				if bound := maxActiveNodes(tasks, syntheticCluster(size, 1)); profile == "binpack" && perRun > bound {
+					b.Fatalf("binpack uses %.1f nodes, want at most %.1f", perRun, bound)
+				}
+			})
+		}
+	}
+}
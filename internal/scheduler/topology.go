@@ -0,0 +1,79 @@
+package scheduler
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/nduyhai/maestro/internal/node"
+	"github.com/nduyhai/maestro/internal/task"
+)
+
+// spreadCounts returns, for each domain of c among the ready nodes matching
+// t's node selector, the number of tasks counting towards the spread of t.
+func spreadCounts(t task.Task, c task.TopologySpread, nodes []*node.Node) map[string]int {
+	counts := make(map[string]int)
+	for _, n := range nodes {
+		domain := n.Topology(c.TopologyKey)
+		if domain == "" || NodeReady(t, n) != nil || MatchNodeSelector(t, n) != nil {
+			continue
+		}
+		if _, ok := counts[domain]; !ok {
+			counts[domain] = 0
+		}
+		for _, other := range n.Tasks {
+			if other.ID != t.ID && c.Matches(t, other) {
+				counts[domain]++
+			}
+		}
+	}
+	return counts
+}
+
+// PrepareTopologySpread builds a check rejecting the nodes where placing t
+// would leave more than MaxSkew matching tasks in their domain above the
+// emptiest domain, for any of t's topology spread constraints. Nodes outside
+// every domain of a constraint are rejected as well.
+func PrepareTopologySpread(t task.Task, nodes []*node.Node) func(task.Task, *node.Node) error {
+	counts := make([]map[string]int, len(t.TopologySpread))
+	for i, c := range t.TopologySpread {
+		counts[i] = spreadCounts(t, c, nodes)
+	}
+	return func(t task.Task, n *node.Node) error {
+		for i, c := range t.TopologySpread {
+			domain := n.Topology(c.TopologyKey)
+			if domain == "" {
+				return fmt.Errorf("node has no %s", c.TopologyKey)
+			}
+			if skew := counts[i][domain] + 1 - minCount(counts[i]); skew > c.MaxSkew {
+				return fmt.Errorf("topology spread %s: skew %d in %s %s exceeds %d", c, skew, c.TopologyKey, domain, c.MaxSkew)
+			}
+		}
+		return nil
+	}
+}
+
+func minCount(counts map[string]int) int {
+	if len(counts) == 0 {
+		return 0
+	}
+	return slices.Min(slices.Collect(maps.Values(counts)))
+}
+
+// PrepareTopologySpreadScore builds a score preferring the nodes whose
+// domains hold the fewest tasks matching t's topology spread constraints.
+// Only the given nodes are counted.
+func PrepareTopologySpreadScore(t task.Task, nodes []*node.Node) func(task.Task, *node.Node) float64 {
+	counts := make([]map[string]int, len(t.TopologySpread))
+	for i, c := range t.TopologySpread {
+		counts[i] = spreadCounts(t, c, nodes)
+	}
+	return func(t task.Task, n *node.Node) float64 {
+		var score float64
+		for i, c := range t.TopologySpread {
+			count := counts[i][n.Topology(c.TopologyKey)]
+			score += float64(count) / float64(count+1)
+		}
+		return score
+	}
+}
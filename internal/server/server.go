@@ -10,13 +10,18 @@ import (
 	"go.uber.org/fx"
 )
 
+type Config struct {
+	Addr string
+}
+
 func RegisterRoutes(
 	lifecycle fx.Lifecycle,
 	route http.Handler,
+	cfg Config,
 ) {
 
 	srv := &http.Server{
-		Addr:              ":8080",
+		Addr:              cfg.Addr,
 		Handler:           route,
 		ReadHeaderTimeout: 10 * time.Second,
 	}
@@ -24,7 +29,7 @@ func RegisterRoutes(
 	lifecycle.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
 			go func() {
-				log.Println("Starting HTTP server on :8080")
+				log.Printf("Starting HTTP server on %s", cfg.Addr)
 				if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 					log.Fatalf("Failed to start server: %v", err)
 				}
@@ -0,0 +1,66 @@
+// Package stats collects the host statistics a worker reports to the manager.
+package stats
+
+import (
+	"github.com/shirou/gopsutil/v4/cpu"
+	"github.com/shirou/gopsutil/v4/disk"
+	"github.com/shirou/gopsutil/v4/load"
+	"github.com/shirou/gopsutil/v4/mem"
+)
+
+type Stats struct {
+	Memory *mem.VirtualMemoryStat
+	CPU    []cpu.InfoStat
+	Cores  int
+	Disk   *disk.UsageStat
+	Load   *load.AvgStat
+}
+
+// Collect reads the statistics of the local host. Values that cannot be read
+// are left empty.
+func Collect() Stats {
+	memory, _ := mem.VirtualMemory()
+	info, _ := cpu.Info()
+	cores, _ := cpu.Counts(true)
+	usage, _ := disk.Usage("/")
+	avg, _ := load.Avg()
+	return Stats{
+		Memory: memory,
+		CPU:    info,
+		Cores:  cores,
+		Disk:   usage,
+		Load:   avg,
+	}
+}
+
+// MemoryTotal returns the total memory of the host in bytes.
+func (s Stats) MemoryTotal() int64 {
+	if s.Memory == nil {
+		return 0
+	}
+	return int64(s.Memory.Total)
+}
+
+// DiskTotal returns the size of the root filesystem in bytes.
+func (s Stats) DiskTotal() int64 {
+	if s.Disk == nil {
+		return 0
+	}
+	return int64(s.Disk.Total)
+}
+
+// MemoryUsed returns the memory in use on the host in bytes.
+func (s Stats) MemoryUsed() int64 {
+	if s.Memory == nil {
+		return 0
+	}
+	return int64(s.Memory.Used)
+}
+
+// Load1 returns the one minute load average of the host.
+func (s Stats) Load1() float64 {
+	if s.Load == nil {
+		return 0
+	}
+	return s.Load.Load1
+}
@@ -0,0 +1,68 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"go.etcd.io/bbolt"
+)
+
+// Bolt stores JSON encoded values in a single bbolt bucket.
+type Bolt[T any] struct {
+	DB     *bbolt.DB
+	Bucket string
+}
+
+func NewBolt[T any](db *bbolt.DB, bucket string) (*Bolt[T], error) {
+	err := db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists([]byte(bucket))
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create bucket %s: %w", bucket, err)
+	}
+	return &Bolt[T]{DB: db, Bucket: bucket}, nil
+}
+
+func (b *Bolt[T]) Put(key string, value T) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return b.DB.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket([]byte(b.Bucket)).Put([]byte(key), data)
+	})
+}
+
+func (b *Bolt[T]) Get(key string) (T, error) {
+	var v T
+	err := b.DB.View(func(tx *bbolt.Tx) error {
+		data := tx.Bucket([]byte(b.Bucket)).Get([]byte(key))
+		if data == nil {
+			return ErrNotFound
+		}
+		return json.Unmarshal(data, &v)
+	})
+	return v, err
+}
+
+func (b *Bolt[T]) Delete(key string) error {
+	return b.DB.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket([]byte(b.Bucket)).Delete([]byte(key))
+	})
+}
+
+func (b *Bolt[T]) List() ([]T, error) {
+	var values []T
+	err := b.DB.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket([]byte(b.Bucket)).ForEach(func(_, data []byte) error {
+			var v T
+			if err := json.Unmarshal(data, &v); err != nil {
+				return err
+			}
+			values = append(values, v)
+			return nil
+		})
+	})
+	return values, err
+}
@@ -0,0 +1,51 @@
+package store
+
+import (
+	"maps"
+	"slices"
+	"sync"
+)
+
+type Memory[T any] struct {
+	mu   sync.RWMutex
+	data map[string]T
+}
+
+func NewMemory[T any]() *Memory[T] {
+	return &Memory[T]{data: make(map[string]T)}
+}
+
+func (m *Memory[T]) Put(key string, value T) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.data[key] = value
+	return nil
+}
+
+func (m *Memory[T]) Get(key string) (T, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	v, ok := m.data[key]
+	if !ok {
+		var zero T
+		return zero, ErrNotFound
+	}
+	return v, nil
+}
+
+func (m *Memory[T]) Delete(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.data, key)
+	return nil
+}
+
+func (m *Memory[T]) List() ([]T, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	values := make([]T, 0, len(m.data))
+	for _, k := range slices.Sorted(maps.Keys(m.data)) {
+		values = append(values, m.data[k])
+	}
+	return values, nil
+}
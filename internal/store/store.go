@@ -0,0 +1,13 @@
+package store
+
+import "errors"
+
+var ErrNotFound = errors.New("not found")
+
+// Store keeps values of a single kind keyed by string. List returns values in key order.
+type Store[T any] interface {
+	Put(key string, value T) error
+	Get(key string) (T, error)
+	Delete(key string) error
+	List() ([]T, error)
+}
@@ -0,0 +1,210 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"os"
+	"strings"
+
+	cerrdefs "github.com/containerd/errdefs"
+	"github.com/go-chi/httplog/v2"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/client"
+)
+
+type Docker struct {
+	Client *client.Client
+	Logger *httplog.Logger
+}
+
+func NewDocker(logger *httplog.Logger) (*Docker, error) {
+	dc, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return nil, err
+	}
+	return &Docker{Client: dc, Logger: logger}, nil
+}
+
+func (d *Docker) Run(ctx context.Context, config Config) DockerResult {
+	reader, err := d.Client.ImagePull(
+		ctx, config.Image, image.PullOptions{})
+	if err != nil {
+		d.Logger.Error("Error pulling image", slog.Any("image", config.Image), slog.Any("error", err))
+		return DockerResult{Error: err}
+	}
+	_, _ = io.Copy(os.Stdout, reader)
+	_ = reader.Close()
+
+	// Restarts are up to the manager, which may run the task elsewhere.
+	rp := container.RestartPolicy{
+		Name: container.RestartPolicyDisabled,
+	}
+
+	r := container.Resources{
+		Memory:   config.Memory,
+		NanoCPUs: int64(config.CPU * math.Pow(10, 9)),
+	}
+
+	cc := container.Config{
+		Image:        config.Image,
+		Tty:          false,
+		Env:          config.Env,
+		Cmd:          config.Cmd,
+		Labels:       config.Labels,
+		ExposedPorts: config.ExposedPorts,
+	}
+
+	hc := container.HostConfig{
+		RestartPolicy: rp,
+		Resources:     r,
+		PortBindings:  config.PortBindings,
+	}
+	resp, err := d.Client.ContainerCreate(ctx, &cc, &hc, nil, nil, config.Name)
+	if err != nil {
+		d.Logger.Error("Error creating container using image", slog.Any("image", config.Image), slog.Any("error", err))
+		return DockerResult{Error: err}
+	}
+
+	err = d.Client.ContainerStart(ctx, resp.ID, container.StartOptions{})
+	if err != nil {
+		d.Logger.Error("Error starting container", slog.Any("ID", resp.ID), slog.Any("error", err))
+		return DockerResult{Error: err}
+	}
+
+	d.Logger.Info("Container created", slog.Any("ID", resp.ID))
+
+	return DockerResult{ContainerID: resp.ID, Action: "start", Result: "success"}
+}
+
+func (d *Docker) Stop(ctx context.Context, id string) DockerResult {
+	d.Logger.Info("Attempting to stop container", slog.Any("ID", id))
+	err := d.Client.ContainerStop(ctx, id, container.StopOptions{})
+	if err != nil {
+		d.Logger.Error("Error stopping container", slog.Any("ID", id), slog.Any("error", err))
+		return DockerResult{Error: notFound(err)}
+	}
+
+	err = d.Client.ContainerRemove(ctx, id, container.RemoveOptions{
+		RemoveVolumes: true,
+		RemoveLinks:   false,
+		Force:         false,
+	})
+	if err != nil {
+		d.Logger.Error("Error removing container", slog.Any("ID", id), slog.Any("error", err))
+		return DockerResult{Error: notFound(err)}
+	}
+
+	return DockerResult{Action: "stop", Result: "success", Error: nil}
+}
+
+func (d *Docker) Inspect(ctx context.Context, containerID string) DockerInspectResponse {
+	resp, err := d.Client.ContainerInspect(ctx, containerID)
+	if err != nil {
+		d.Logger.Error("Error inspecting container", slog.Any("ID", containerID), slog.Any("error", err))
+		return DockerInspectResponse{Error: notFound(err)}
+	}
+
+	return DockerInspectResponse{Container: &resp}
+}
+
+func (d *Docker) Logs(ctx context.Context, containerID string) (io.ReadCloser, error) {
+	return d.Client.ContainerLogs(ctx, containerID, container.LogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Timestamps: true,
+	})
+}
+
+func (d *Docker) Stats(ctx context.Context, containerID string) (ContainerStats, error) {
+	resp, err := d.Client.ContainerStatsOneShot(ctx, containerID)
+	if err != nil {
+		return ContainerStats{}, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var s container.StatsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&s); err != nil {
+		return ContainerStats{}, err
+	}
+
+	var cpuPercent float64
+	cpuDelta := float64(s.CPUStats.CPUUsage.TotalUsage) - float64(s.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(s.CPUStats.SystemUsage) - float64(s.PreCPUStats.SystemUsage)
+	if cpuDelta > 0 && systemDelta > 0 {
+		cpuPercent = cpuDelta / systemDelta * float64(s.CPUStats.OnlineCPUs) * 100
+	}
+
+	return ContainerStats{
+		CPUPercent:  cpuPercent,
+		MemoryUsage: s.MemoryStats.Usage,
+		MemoryLimit: s.MemoryStats.Limit,
+	}, nil
+}
+
+func (d *Docker) Exec(ctx context.Context, containerID string, cmd []string) (int, error) {
+	exec, err := d.Client.ContainerExecCreate(ctx, containerID, container.ExecOptions{
+		Cmd:          cmd,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return 0, err
+	}
+	resp, err := d.Client.ContainerExecAttach(ctx, exec.ID, container.ExecAttachOptions{})
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Close()
+	// The exec is done once its output is drained.
+	if _, err := io.Copy(io.Discard, resp.Reader); err != nil {
+		return 0, err
+	}
+
+	inspect, err := d.Client.ContainerExecInspect(ctx, exec.ID)
+	if err != nil {
+		return 0, err
+	}
+	return inspect.ExitCode, nil
+}
+
+func (d *Docker) List(ctx context.Context) ([]ContainerSummary, error) {
+	containers, err := d.Client.ContainerList(ctx, container.ListOptions{
+		All:     true,
+		Filters: filters.NewArgs(filters.Arg("label", LabelTaskID)),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make([]ContainerSummary, 0, len(containers))
+	for _, c := range containers {
+		var name string
+		if len(c.Names) > 0 {
+			name = strings.TrimPrefix(c.Names[0], "/")
+		}
+		summaries = append(summaries, ContainerSummary{
+			ID:     c.ID,
+			Name:   name,
+			Image:  c.Image,
+			State:  string(c.State),
+			Labels: c.Labels,
+		})
+	}
+	return summaries, nil
+}
+
+// notFound wraps err in ErrContainerNotFound when Docker reports that the
+// container does not exist.
+func notFound(err error) error {
+	if cerrdefs.IsNotFound(err) {
+		return fmt.Errorf("%w: %w", ErrContainerNotFound, err)
+	}
+	return err
+}
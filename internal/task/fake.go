@@ -0,0 +1,283 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/go-connections/nat"
+)
+
+// FakeBehavior describes how containers started from an image behave in a Fake runtime.
+type FakeBehavior struct {
+	RunError  error
+	ExitAfter time.Duration
+	ExitCode  int
+	Logs      string
+	// ExecExitCode is the exit code of the commands run with Exec.
+	ExecExitCode int
+}
+
+// Fake is an in-memory Runtime that simulates container lifecycles without Docker.
+type Fake struct {
+	Behaviors map[string]FakeBehavior
+	Now       func() time.Time
+
+	mu         sync.Mutex
+	seq        int
+	nextPort   int
+	containers map[string]*fakeContainer
+}
+
+type fakeContainer struct {
+	id         string
+	config     Config
+	behavior   FakeBehavior
+	status     container.ContainerState
+	exitCode   int
+	err        string
+	oomKilled  bool
+	startedAt  time.Time
+	finishedAt time.Time
+	ports      nat.PortMap
+}
+
+func NewFake() *Fake {
+	return &Fake{
+		Behaviors:  make(map[string]FakeBehavior),
+		Now:        time.Now,
+		nextPort:   32768,
+		containers: make(map[string]*fakeContainer),
+	}
+}
+
+func (f *Fake) Run(_ context.Context, config Config) DockerResult {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	b := f.Behaviors[config.Image]
+	if b.RunError != nil {
+		return DockerResult{Error: b.RunError}
+	}
+
+	for p, bindings := range config.PortBindings {
+		for _, b := range bindings {
+			if b.HostPort != "" && f.hostPortInUse(p.Proto(), b.HostPort) {
+				return DockerResult{Error: fmt.Errorf("bind for 0.0.0.0:%s failed: port is already allocated", b.HostPort)}
+			}
+		}
+	}
+
+	f.seq++
+	c := &fakeContainer{
+		id:        fmt.Sprintf("fake-%06d", f.seq),
+		config:    config,
+		behavior:  b,
+		status:    container.StateRunning,
+		startedAt: f.Now().UTC(),
+		ports:     nat.PortMap{},
+	}
+	for p := range config.ExposedPorts {
+		if bindings := config.PortBindings[p]; len(bindings) > 0 && bindings[0].HostPort != "" {
+			c.ports[p] = []nat.PortBinding{{HostIP: "0.0.0.0", HostPort: bindings[0].HostPort}}
+			continue
+		}
+		c.ports[p] = []nat.PortBinding{{HostIP: "0.0.0.0", HostPort: strconv.Itoa(f.nextPort)}}
+		f.nextPort++
+	}
+	f.containers[c.id] = c
+
+	return DockerResult{ContainerID: c.id, Action: "start", Result: "success"}
+}
+
+// hostPortInUse reports whether a running container is published on the host
+// port; callers must hold f.mu.
+func (f *Fake) hostPortInUse(proto, hostPort string) bool {
+	for _, c := range f.containers {
+		if c.status != container.StateRunning {
+			continue
+		}
+		for p, bindings := range c.ports {
+			for _, b := range bindings {
+				if p.Proto() == proto && b.HostPort == hostPort {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func (f *Fake) Stop(_ context.Context, containerID string) DockerResult {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.containers[containerID]; !ok {
+		return DockerResult{Error: fmt.Errorf("%w: %s", ErrContainerNotFound, containerID)}
+	}
+	delete(f.containers, containerID)
+
+	return DockerResult{Action: "stop", Result: "success"}
+}
+
+func (f *Fake) Inspect(_ context.Context, containerID string) DockerInspectResponse {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	c, ok := f.containers[containerID]
+	if !ok {
+		return DockerInspectResponse{Error: fmt.Errorf("%w: %s", ErrContainerNotFound, containerID)}
+	}
+	f.advance(c)
+
+	state := &container.State{
+		Status:    c.status,
+		Running:   c.status == container.StateRunning,
+		OOMKilled: c.oomKilled,
+		Dead:      c.status == container.StateDead,
+		ExitCode:  c.exitCode,
+		Error:     c.err,
+		StartedAt: c.startedAt.Format(time.RFC3339Nano),
+	}
+	if !c.finishedAt.IsZero() {
+		state.FinishedAt = c.finishedAt.Format(time.RFC3339Nano)
+	}
+
+	return DockerInspectResponse{Container: &container.InspectResponse{
+		ContainerJSONBase: &container.ContainerJSONBase{
+			ID:    c.id,
+			Name:  "/" + c.config.Name,
+			Image: c.config.Image,
+			State: state,
+		},
+		Config: &container.Config{
+			Image:        c.config.Image,
+			Env:          c.config.Env,
+			Cmd:          c.config.Cmd,
+			Labels:       c.config.Labels,
+			ExposedPorts: c.config.ExposedPorts,
+		},
+		NetworkSettings: &container.NetworkSettings{
+			NetworkSettingsBase: container.NetworkSettingsBase{Ports: c.ports},
+		},
+	}}
+}
+
+func (f *Fake) Logs(_ context.Context, containerID string) (io.ReadCloser, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	c, ok := f.containers[containerID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrContainerNotFound, containerID)
+	}
+	return io.NopCloser(strings.NewReader(c.behavior.Logs)), nil
+}
+
+func (f *Fake) Stats(_ context.Context, containerID string) (ContainerStats, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	c, ok := f.containers[containerID]
+	if !ok {
+		return ContainerStats{}, fmt.Errorf("%w: %s", ErrContainerNotFound, containerID)
+	}
+	f.advance(c)
+	if c.status != container.StateRunning {
+		return ContainerStats{}, nil
+	}
+
+	return ContainerStats{
+		CPUPercent:  c.config.CPU * 100,
+		MemoryUsage: uint64(max(c.config.Memory, 0)) / 2,
+		MemoryLimit: uint64(max(c.config.Memory, 0)),
+	}, nil
+}
+
+func (f *Fake) List(_ context.Context) ([]ContainerSummary, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	summaries := make([]ContainerSummary, 0, len(f.containers))
+	for _, id := range slices.Sorted(maps.Keys(f.containers)) {
+		c := f.containers[id]
+		f.advance(c)
+		summaries = append(summaries, ContainerSummary{
+			ID:     c.id,
+			Name:   c.config.Name,
+			Image:  c.config.Image,
+			State:  string(c.status),
+			Labels: c.config.Labels,
+		})
+	}
+	return summaries, nil
+}
+
+func (f *Fake) Exec(_ context.Context, containerID string, _ []string) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	c, ok := f.containers[containerID]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrContainerNotFound, containerID)
+	}
+	f.advance(c)
+	if c.status != container.StateRunning {
+		return 0, fmt.Errorf("container %s is not running", containerID)
+	}
+	return c.behavior.ExecExitCode, nil
+}
+
+// Exit simulates the main process of a running container exiting with code.
+func (f *Fake) Exit(containerID string, code int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	c, ok := f.containers[containerID]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrContainerNotFound, containerID)
+	}
+	f.finish(c, code, "")
+	return nil
+}
+
+// Crash simulates a container being killed, for example by the OOM killer.
+func (f *Fake) Crash(containerID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	c, ok := f.containers[containerID]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrContainerNotFound, containerID)
+	}
+	c.oomKilled = true
+	f.finish(c, 137, "container crashed")
+	return nil
+}
+
+func (f *Fake) advance(c *fakeContainer) {
+	if c.status != container.StateRunning || c.behavior.ExitAfter <= 0 {
+		return
+	}
+	if f.Now().Sub(c.startedAt) >= c.behavior.ExitAfter {
+		f.finish(c, c.behavior.ExitCode, "")
+		c.finishedAt = c.startedAt.Add(c.behavior.ExitAfter)
+	}
+}
+
+func (f *Fake) finish(c *fakeContainer, code int, reason string) {
+	if c.status != container.StateRunning {
+		return
+	}
+	c.status = container.StateExited
+	c.exitCode = code
+	c.err = reason
+	c.finishedAt = f.Now().UTC()
+}
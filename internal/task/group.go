@@ -0,0 +1,20 @@
+package task
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Group is a set of tasks that are placed all at once or not at all. When
+// they cannot all be placed within Timeout of the submission, every member
+// fails; a zero Timeout waits for as long as it takes. Deadline and Placed
+// are maintained by the manager.
+type Group struct {
+	ID       uuid.UUID
+	Name     string
+	Tasks    []Task
+	Timeout  time.Duration
+	Deadline time.Time
+	Placed   bool
+}
@@ -0,0 +1,91 @@
+package task
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/docker/go-connections/nat"
+)
+
+// Health is the outcome of the health checks of a running task.
+type Health string
+
+const (
+	// HealthStarting is the health of a task that has not passed a check yet.
+	HealthStarting  Health = "starting"
+	HealthHealthy   Health = "healthy"
+	HealthUnhealthy Health = "unhealthy"
+)
+
+// Default settings of a health check.
+const (
+	DefaultHealthInterval  = 10 * time.Second
+	DefaultHealthTimeout   = 5 * time.Second
+	DefaultHealthThreshold = 3
+)
+
+// HealthCheck is run by the worker against a running task. Exactly one of
+// HTTP, TCP and Exec is set. HTTP and TCP checks connect to the host port
+// Port is published on; an HTTP check passes on any 2xx or 3xx response to a
+// GET of Path. An exec check passes when Exec exits with code 0 in the
+// container. A task that fails FailureThreshold checks in a row is
+// unhealthy: the worker stops it and it fails. Checks failing within
+// StartPeriod of the task starting are not counted, to give it time to boot.
+type HealthCheck struct {
+	HTTP             bool
+	TCP              bool
+	Exec             []string
+	Port             int
+	Path             string
+	Interval         time.Duration
+	Timeout          time.Duration
+	FailureThreshold int
+	StartPeriod      time.Duration
+}
+
+func (h *HealthCheck) Validate() error {
+	kinds := 0
+	for _, set := range []bool{h.HTTP, h.TCP, len(h.Exec) > 0} {
+		if set {
+			kinds++
+		}
+	}
+	if kinds != 1 {
+		return errors.New("health check needs exactly one of HTTP, TCP and Exec")
+	}
+	if (h.HTTP || h.TCP) && (h.Port < 1 || h.Port > 65535) {
+		return fmt.Errorf("invalid health check port %d", h.Port)
+	}
+	if h.Interval < 0 || h.Timeout < 0 || h.FailureThreshold < 0 || h.StartPeriod < 0 {
+		return errors.New("health check interval, timeout, failure threshold and start period cannot be negative")
+	}
+	return nil
+}
+
+// ContainerPort returns the container port an HTTP or TCP check connects to.
+func (h *HealthCheck) ContainerPort() nat.Port {
+	return nat.Port(strconv.Itoa(h.Port) + "/tcp")
+}
+
+// WithDefaults returns h with the unset interval, timeout and threshold
+// replaced by their defaults.
+func (h HealthCheck) WithDefaults() HealthCheck {
+	if h.Interval == 0 {
+		h.Interval = DefaultHealthInterval
+	}
+	if h.Timeout == 0 {
+		h.Timeout = DefaultHealthTimeout
+	}
+	if h.FailureThreshold == 0 {
+		h.FailureThreshold = DefaultHealthThreshold
+	}
+	return h
+}
+
+// Ready reports whether t is running and serving: a task with a health check
+// only once a check has passed.
+func (t *Task) Ready() bool {
+	return t.State == Running && (t.HealthCheck == nil || t.Health == HealthHealthy)
+}
@@ -0,0 +1,77 @@
+package task
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/docker/go-connections/nat"
+)
+
+// HostPortRequests returns the host ports, with the protocol of their
+// container port, that BindingPorts asks for, such as "8080/tcp".
+func (t Task) HostPortRequests() ([]nat.Port, error) {
+	var ports []nat.Port
+	for containerPort, hostPort := range t.BindingPorts {
+		proto, port := nat.SplitProtoPort(containerPort)
+		if _, err := nat.ParsePort(port); err != nil || port == "" {
+			return nil, fmt.Errorf("invalid container port %q", containerPort)
+		}
+		if n, err := strconv.Atoi(hostPort); err != nil || n < 1 || n > 65535 {
+			return nil, fmt.Errorf("invalid host port %q for %s", hostPort, containerPort)
+		}
+		p, err := nat.NewPort(proto, hostPort)
+		if err != nil {
+			return nil, err
+		}
+		if slices.Contains(ports, p) {
+			return nil, fmt.Errorf("host port %s bound more than once", p)
+		}
+		ports = append(ports, p)
+	}
+	SortPorts(ports)
+	return ports, nil
+}
+
+// UsedHostPorts returns the host ports the task asked for together with those
+// its container is published on.
+func (t Task) UsedHostPorts() []nat.Port {
+	ports, _ := t.HostPortRequests()
+	for containerPort, bindings := range t.HostPorts {
+		for _, b := range bindings {
+			if b.HostPort == "" {
+				continue
+			}
+			if p, err := nat.NewPort(containerPort.Proto(), b.HostPort); err == nil && !slices.Contains(ports, p) {
+				ports = append(ports, p)
+			}
+		}
+	}
+	SortPorts(ports)
+	return ports
+}
+
+// SortPorts sorts ports by number, then protocol.
+func SortPorts(ports []nat.Port) {
+	slices.SortFunc(ports, func(a, b nat.Port) int {
+		return cmp.Or(cmp.Compare(a.Int(), b.Int()), cmp.Compare(a.Proto(), b.Proto()))
+	})
+}
+
+// portBindings returns the bindings of BindingPorts in Docker's form.
+func (t Task) portBindings() nat.PortMap {
+	if len(t.BindingPorts) == 0 {
+		return nil
+	}
+	bindings := make(nat.PortMap, len(t.BindingPorts))
+	for containerPort, hostPort := range t.BindingPorts {
+		proto, port := nat.SplitProtoPort(containerPort)
+		p, err := nat.NewPort(proto, port)
+		if err != nil {
+			continue
+		}
+		bindings[p] = []nat.PortBinding{{HostPort: hostPort}}
+	}
+	return bindings
+}
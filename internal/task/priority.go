@@ -0,0 +1,26 @@
+package task
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// PriorityClasses name common task priorities. Tasks default to normal.
+var PriorityClasses = map[string]int{
+	"low":      -100,
+	"normal":   0,
+	"high":     100,
+	"critical": 1000,
+}
+
+// ParsePriority accepts the name of a priority class or an integer.
+func ParsePriority(s string) (int, error) {
+	if p, ok := PriorityClasses[s]; ok {
+		return p, nil
+	}
+	p, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("unknown priority class %q", s)
+	}
+	return p, nil
+}
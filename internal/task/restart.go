@@ -0,0 +1,76 @@
+package task
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// RestartPolicy tells the manager whether to run a task again once it stops.
+// Tasks default to never being restarted.
+type RestartPolicy string
+
+const (
+	RestartNever     RestartPolicy = "never"
+	RestartOnFailure RestartPolicy = "on-failure"
+	RestartAlways    RestartPolicy = "always"
+)
+
+// ReasonStopped is the exit reason of a task stopped on request, which is not
+// restarted whatever its policy.
+const ReasonStopped = "stopped"
+
+// normalize maps the Docker names of the policies to their equivalents.
+func (p RestartPolicy) normalize() RestartPolicy {
+	switch p {
+	case "", "no":
+		return RestartNever
+	case "unless-stopped":
+		return RestartAlways
+	default:
+		return p
+	}
+}
+
+func (p RestartPolicy) Validate() error {
+	switch p.normalize() {
+	case RestartNever, RestartOnFailure, RestartAlways:
+		return nil
+	default:
+		return fmt.Errorf("unknown restart policy %q (known: never, on-failure, always)", string(p))
+	}
+}
+
+// ParseRestartPolicy parses never, always or on-failure[:MAX], returning the
+// policy and the maximum number of restarts on failure.
+func ParseRestartPolicy(s string) (RestartPolicy, int, error) {
+	name, limit, found := strings.Cut(s, ":")
+	p := RestartPolicy(name)
+	if err := p.Validate(); err != nil {
+		return "", 0, err
+	}
+	if !found {
+		return p, 0, nil
+	}
+	if p.normalize() != RestartOnFailure {
+		return "", 0, fmt.Errorf("restart policy %s takes no maximum", name)
+	}
+	n, err := strconv.Atoi(limit)
+	if err != nil || n < 0 {
+		return "", 0, fmt.Errorf("invalid maximum number of restarts %q", limit)
+	}
+	return p, n, nil
+}
+
+// ShouldRestart reports whether t, which stopped running in its current
+// state, is to be run again.
+func (t *Task) ShouldRestart() bool {
+	switch t.RestartPolicy.normalize() {
+	case RestartAlways:
+		return t.State == Failed || (t.State == Completed && t.ExitReason != ReasonStopped)
+	case RestartOnFailure:
+		return t.State == Failed && (t.MaxRestarts == 0 || t.RestartCount < t.MaxRestarts)
+	default:
+		return false
+	}
+}
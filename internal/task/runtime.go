@@ -0,0 +1,55 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+// Runtime is the container engine a worker uses to run its tasks.
+type Runtime interface {
+	Run(ctx context.Context, config Config) DockerResult
+	Stop(ctx context.Context, containerID string) DockerResult
+	Inspect(ctx context.Context, containerID string) DockerInspectResponse
+	Logs(ctx context.Context, containerID string) (io.ReadCloser, error)
+	Stats(ctx context.Context, containerID string) (ContainerStats, error)
+	List(ctx context.Context) ([]ContainerSummary, error)
+	// Exec runs cmd in a running container and returns its exit code.
+	Exec(ctx context.Context, containerID string, cmd []string) (int, error)
+}
+
+// ErrContainerNotFound is returned by a Runtime for a container that does not
+// exist, as opposed to one it failed to reach.
+var ErrContainerNotFound = errors.New("container not found")
+
+// LabelTaskID is set on every container started for a task so that a worker
+// can find the containers it owns after a restart.
+const LabelTaskID = "maestro.task.id"
+
+type DockerResult struct {
+	Error       error
+	Action      string
+	ContainerID string
+	Result      string
+}
+
+type DockerInspectResponse struct {
+	Error     error
+	Container *container.InspectResponse
+}
+
+type ContainerStats struct {
+	CPUPercent  float64
+	MemoryUsage uint64
+	MemoryLimit uint64
+}
+
+type ContainerSummary struct {
+	ID     string
+	Name   string
+	Image  string
+	State  string
+	Labels map[string]string
+}
@@ -0,0 +1,178 @@
+package task
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+type Operator string
+
+const (
+	In           Operator = "In"
+	NotIn        Operator = "NotIn"
+	Exists       Operator = "Exists"
+	DoesNotExist Operator = "DoesNotExist"
+)
+
+// Requirement is a single condition on the value of a label.
+type Requirement struct {
+	Key      string
+	Operator Operator
+	Values   []string
+}
+
+// Selector matches a set of labels when all of MatchLabels are present with
+// the given values and every requirement of MatchExpressions holds.
+type Selector struct {
+	MatchLabels      map[string]string
+	MatchExpressions []Requirement
+}
+
+func (r Requirement) Matches(labels map[string]string) bool {
+	v, ok := labels[r.Key]
+	switch r.Operator {
+	case In:
+		return ok && slices.Contains(r.Values, v)
+	case NotIn:
+		return !ok || !slices.Contains(r.Values, v)
+	case Exists:
+		return ok
+	case DoesNotExist:
+		return !ok
+	default:
+		return false
+	}
+}
+
+func (r Requirement) String() string {
+	switch r.Operator {
+	case In:
+		if len(r.Values) == 1 {
+			return r.Key + "=" + r.Values[0]
+		}
+		return fmt.Sprintf("%s in (%s)", r.Key, strings.Join(r.Values, ","))
+	case NotIn:
+		if len(r.Values) == 1 {
+			return r.Key + "!=" + r.Values[0]
+		}
+		return fmt.Sprintf("%s notin (%s)", r.Key, strings.Join(r.Values, ","))
+	case Exists:
+		return r.Key
+	case DoesNotExist:
+		return "!" + r.Key
+	default:
+		return fmt.Sprintf("%s %s (%s)", r.Key, r.Operator, strings.Join(r.Values, ","))
+	}
+}
+
+func (s Selector) Matches(labels map[string]string) bool {
+	for k, v := range s.MatchLabels {
+		if lv, ok := labels[k]; !ok || lv != v {
+			return false
+		}
+	}
+	for _, r := range s.MatchExpressions {
+		if !r.Matches(labels) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s Selector) String() string {
+	var parts []string
+	for _, k := range slices.Sorted(maps.Keys(s.MatchLabels)) {
+		parts = append(parts, k+"="+s.MatchLabels[k])
+	}
+	for _, r := range s.MatchExpressions {
+		parts = append(parts, r.String())
+	}
+	return strings.Join(parts, ",")
+}
+
+// ParseSelector parses a comma separated list of requirements, each one of
+// "key=value", "key!=value", "key in (a,b)", "key notin (a,b)", "key" or "!key".
+func ParseSelector(s string) (Selector, error) {
+	var sel Selector
+	for _, part := range splitRequirements(s) {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		r, err := parseRequirement(part)
+		if err != nil {
+			return Selector{}, err
+		}
+		sel.MatchExpressions = append(sel.MatchExpressions, r)
+	}
+	if len(sel.MatchExpressions) == 0 {
+		return Selector{}, fmt.Errorf("empty selector %q", s)
+	}
+	return sel, nil
+}
+
+// splitRequirements splits s on the commas that are not inside parentheses.
+func splitRequirements(s string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i, c := range s {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
+
+func parseRequirement(s string) (Requirement, error) {
+	if key, value, ok := strings.Cut(s, "!="); ok {
+		return requirement(key, NotIn, value)
+	}
+	if key, value, ok := strings.Cut(s, "="); ok {
+		return requirement(key, In, strings.TrimPrefix(value, "="))
+	}
+	fields := strings.Fields(s)
+	if len(fields) >= 2 && (fields[1] == "in" || fields[1] == "notin") {
+		list := strings.TrimSpace(strings.Join(fields[2:], " "))
+		if !strings.HasPrefix(list, "(") || !strings.HasSuffix(list, ")") {
+			return Requirement{}, fmt.Errorf("invalid requirement %q: values must be in parentheses", s)
+		}
+		op := In
+		if fields[1] == "notin" {
+			op = NotIn
+		}
+		return requirement(fields[0], op, strings.Split(list[1:len(list)-1], ",")...)
+	}
+	if len(fields) == 1 {
+		if key, ok := strings.CutPrefix(fields[0], "!"); ok {
+			return requirement(key, DoesNotExist)
+		}
+		return requirement(fields[0], Exists)
+	}
+	return Requirement{}, fmt.Errorf("invalid requirement %q", s)
+}
+
+func requirement(key string, op Operator, values ...string) (Requirement, error) {
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return Requirement{}, fmt.Errorf("missing label key in requirement")
+	}
+	r := Requirement{Key: key, Operator: op}
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			return Requirement{}, fmt.Errorf("empty value for label %q", key)
+		}
+		r.Values = append(r.Values, v)
+	}
+	return r, nil
+}
@@ -0,0 +1,58 @@
+package task
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// TopologySpread limits how unevenly the tasks matching Selector may be spread
+// across the failure domains named by TopologyKey: "zone", "rack" or a node
+// label. A domain may hold at most MaxSkew more of them than the emptiest one.
+// An empty Selector matches the tasks carrying all of the task's own labels.
+type TopologySpread struct {
+	TopologyKey string
+	MaxSkew     int
+	Selector    Selector
+}
+
+// Matches reports whether other counts towards the spread of t.
+func (c TopologySpread) Matches(t, other Task) bool {
+	if len(c.Selector.MatchLabels) == 0 && len(c.Selector.MatchExpressions) == 0 {
+		return Selector{MatchLabels: t.Labels}.Matches(other.Labels)
+	}
+	return c.Selector.Matches(other.Labels)
+}
+
+func (c TopologySpread) String() string {
+	s := fmt.Sprintf("%s:%d", c.TopologyKey, c.MaxSkew)
+	if sel := c.Selector.String(); sel != "" {
+		s += ":" + sel
+	}
+	return s
+}
+
+// ParseTopologySpread parses a constraint written as "key[:maxSkew[:selector]]".
+// MaxSkew defaults to 1.
+func ParseTopologySpread(s string) (TopologySpread, error) {
+	parts := strings.SplitN(s, ":", 3)
+	c := TopologySpread{TopologyKey: strings.TrimSpace(parts[0]), MaxSkew: 1}
+	if c.TopologyKey == "" {
+		return TopologySpread{}, fmt.Errorf("topology spread %q has no key", s)
+	}
+	if len(parts) > 1 {
+		skew, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+		if err != nil || skew < 1 {
+			return TopologySpread{}, fmt.Errorf("topology spread %q: max skew must be a positive number", s)
+		}
+		c.MaxSkew = skew
+	}
+	if len(parts) > 2 {
+		sel, err := ParseSelector(parts[2])
+		if err != nil {
+			return TopologySpread{}, err
+		}
+		c.Selector = sel
+	}
+	return c, nil
+}
@@ -0,0 +1,112 @@
+package task
+
+import (
+	"fmt"
+	"strings"
+)
+
+type TaintEffect string
+
+const (
+	// NoSchedule keeps tasks that do not tolerate the taint off the node.
+	NoSchedule TaintEffect = "NoSchedule"
+	// PreferNoSchedule makes the scheduler avoid the node when it can.
+	PreferNoSchedule TaintEffect = "PreferNoSchedule"
+	// NoExecute also evicts the tasks already on the node that do not tolerate it.
+	NoExecute TaintEffect = "NoExecute"
+)
+
+// Taint marks a node so that only tasks tolerating it are placed there.
+type Taint struct {
+	Key    string
+	Value  string
+	Effect TaintEffect
+}
+
+type TolerationOperator string
+
+const (
+	TolerationEqual  TolerationOperator = "Equal"
+	TolerationExists TolerationOperator = "Exists"
+)
+
+// Toleration lets a task run on nodes with matching taints. An empty Effect
+// tolerates every effect and the Exists operator ignores the taint's value.
+type Toleration struct {
+	Key      string
+	Operator TolerationOperator
+	Value    string
+	Effect   TaintEffect
+}
+
+func (t Taint) String() string {
+	s := t.Key
+	if t.Value != "" {
+		s += "=" + t.Value
+	}
+	return s + ":" + string(t.Effect)
+}
+
+func (tol Toleration) Tolerates(t Taint) bool {
+	if tol.Key != t.Key || (tol.Effect != "" && tol.Effect != t.Effect) {
+		return false
+	}
+	return tol.Operator == TolerationExists || tol.Value == t.Value
+}
+
+// Tolerates reports whether any of the task's tolerations matches t.
+func (t Task) Tolerates(taint Taint) bool {
+	for _, tol := range t.Tolerations {
+		if tol.Tolerates(taint) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseTaint parses a taint written as "key=value:Effect" or "key:Effect".
+func ParseTaint(s string) (Taint, error) {
+	kv, effect, ok := strings.Cut(s, ":")
+	if !ok {
+		return Taint{}, fmt.Errorf("taint %q has no effect", s)
+	}
+	key, value, _ := strings.Cut(kv, "=")
+	t := Taint{Key: strings.TrimSpace(key), Value: strings.TrimSpace(value), Effect: TaintEffect(strings.TrimSpace(effect))}
+	if t.Key == "" {
+		return Taint{}, fmt.Errorf("taint %q has no key", s)
+	}
+	if err := t.Effect.validate(); err != nil {
+		return Taint{}, err
+	}
+	return t, nil
+}
+
+// ParseToleration parses a toleration written as "key=value[:Effect]", which
+// requires an equal value, or "key[:Effect]", which accepts any value.
+func ParseToleration(s string) (Toleration, error) {
+	kv, effect, _ := strings.Cut(s, ":")
+	key, value, equal := strings.Cut(kv, "=")
+	tol := Toleration{Key: strings.TrimSpace(key), Operator: TolerationExists, Effect: TaintEffect(strings.TrimSpace(effect))}
+	if equal {
+		tol.Operator = TolerationEqual
+		tol.Value = strings.TrimSpace(value)
+	}
+	if tol.Key == "" {
+		return Toleration{}, fmt.Errorf("toleration %q has no key", s)
+	}
+	if tol.Effect != "" {
+		if err := tol.Effect.validate(); err != nil {
+			return Toleration{}, err
+		}
+	}
+	return tol, nil
+}
+
+func (e TaintEffect) validate() error {
+	switch e {
+	case NoSchedule, PreferNoSchedule, NoExecute:
+		return nil
+	default:
+		return fmt.Errorf("unknown taint effect %q", e)
+	}
+}
@@ -1,20 +1,10 @@
 package task
 
 import (
-	"context"
-	"io"
-	"log"
-	"log/slog"
-	"math"
-	"os"
+	"fmt"
+	"maps"
 	"time"
 
-	"github.com/go-chi/httplog/v2"
-
-	"github.com/docker/docker/api/types/container"
-	"github.com/docker/docker/api/types/image"
-	"github.com/docker/docker/client"
-
 	"github.com/docker/go-connections/nat"
 	"github.com/google/uuid"
 )
@@ -29,24 +19,72 @@ const (
 	Failed
 )
 
+func (s State) String() string {
+	switch s {
+	case Pending:
+		return "Pending"
+	case Scheduled:
+		return "Scheduled"
+	case Running:
+		return "Running"
+	case Completed:
+		return "Completed"
+	case Failed:
+		return "Failed"
+	default:
+		return fmt.Sprintf("State(%d)", int(s))
+	}
+}
+
 type Task struct {
-	ID            uuid.UUID
-	Name          string
-	State         State
-	Image         string
-	CPU           float64
-	Memory        int64
-	Disk          int64
-	ExposedPorts  nat.PortSet
-	BindingPorts  map[string]string
-	RestartPolicy container.RestartPolicyMode
-	StartTime     time.Time
-	EndTime       time.Time
-	FinishTime    time.Time
-	ContainerID   string
-	Env           []string
-	Cmd           []string
-	HostPorts     nat.PortMap
+	ID           uuid.UUID
+	Name         string
+	State        State
+	Image        string
+	CPU          float64
+	Memory       int64
+	Disk         int64
+	ExposedPorts nat.PortSet
+	// BindingPorts publishes container ports, such as "80/tcp", on the given
+	// host ports. Other exposed ports are published on random host ports.
+	BindingPorts map[string]string
+	// RestartPolicy is applied by the manager when the task stops running.
+	// MaxRestarts bounds the restarts of an on-failure policy; zero means
+	// no bound.
+	RestartPolicy RestartPolicy
+	MaxRestarts   int
+	// RestartCount is the number of times the manager ran the task again,
+	// and ExitReason why it last stopped running.
+	RestartCount int
+	ExitReason   string
+	StartTime    time.Time
+	EndTime      time.Time
+	FinishTime   time.Time
+	ContainerID  string
+	Env          []string
+	Cmd          []string
+	HostPorts    nat.PortMap
+	Node         string
+	Labels       map[string]string
+	NodeSelector map[string]string
+	// Affinity requires, for each selector, a task matching it on the node.
+	// AntiAffinity excludes nodes running a task that matches any selector.
+	Affinity     []Selector
+	AntiAffinity []Selector
+	Tolerations  []Toleration
+	// TopologySpread spreads the task and its peers across failure domains.
+	TopologySpread []TopologySpread
+	// Priority orders pending tasks and lets a task preempt lower ones.
+	Priority int
+	// Group is the ID of the group the task is placed with, if any.
+	Group uuid.UUID
+	// SchedulerProfile names the scheduler profile placing the task; empty
+	// means the manager's default.
+	SchedulerProfile string
+	// HealthCheck, when set, is run by the worker while the task runs, and
+	// Health is its latest outcome.
+	HealthCheck *HealthCheck
+	Health      Health
 }
 
 type Event struct {
@@ -54,146 +92,86 @@ type Event struct {
 	State     State
 	Timestamp time.Time
 	Task      Task
+	Message   string
+	// NotBefore delays the dispatch of the event, e.g. to back off restarts.
+	NotBefore time.Time
+	// Seq is assigned by the manager when it records the event, in
+	// increasing order, so that watchers can page through the history.
+	Seq uint64
 }
 
 type Config struct {
-	Name          string
-	AttachStdin   bool
-	AttachStdout  bool
-	AttachStderr  bool
-	ExposedPorts  nat.PortSet
-	Cmd           []string
-	Image         string
-	CPU           float64
-	Memory        int64
-	Disk          int64
-	Env           []string
-	RestartPolicy container.RestartPolicyMode
+	Name         string
+	AttachStdin  bool
+	AttachStdout bool
+	AttachStderr bool
+	ExposedPorts nat.PortSet
+	PortBindings nat.PortMap
+	Cmd          []string
+	Image        string
+	CPU          float64
+	Memory       int64
+	Disk         int64
+	Env          []string
+	Labels       map[string]string
 }
 
 func NewConfig(t *Task) Config {
-	return Config{
-		Name:          t.Name,
-		AttachStdin:   false,
-		AttachStdout:  false,
-		AttachStderr:  false,
-		ExposedPorts:  t.ExposedPorts,
-		Cmd:           t.Cmd,
-		Image:         t.Image,
-		CPU:           t.CPU,
-		Memory:        t.Memory,
-		Disk:          t.Disk,
-		Env:           t.Env,
-		RestartPolicy: t.RestartPolicy,
-	}
-}
-
-type Docker struct {
-	Client *client.Client
-	Config Config
-	Logger *httplog.Logger
-}
-
-func NewDocker(config Config, Logger *httplog.Logger) *Docker {
-	dc, _ := client.NewClientWithOpts(client.FromEnv)
-	return &Docker{Config: config, Client: dc, Logger: Logger}
-}
-
-func (d *Docker) Run() DockerResult {
-	ctx := context.Background()
-	reader, err := d.Client.ImagePull(
-		ctx, d.Config.Image, image.PullOptions{})
-	if err != nil {
-		d.Logger.Error("Error pulling image", slog.Any("image", d.Config.Image), slog.Any("error", err))
-		return DockerResult{Error: err}
-	}
-	_, _ = io.Copy(os.Stdout, reader)
-
-	rp := container.RestartPolicy{
-		Name: d.Config.RestartPolicy,
-	}
-
-	r := container.Resources{
-		Memory:   d.Config.Memory,
-		NanoCPUs: int64(d.Config.CPU * math.Pow(10, 9)),
-	}
-
-	cc := container.Config{
-		Image:        d.Config.Image,
-		Tty:          false,
-		Env:          d.Config.Env,
-		ExposedPorts: d.Config.ExposedPorts,
-	}
-
-	hc := container.HostConfig{
-		RestartPolicy:   rp,
-		Resources:       r,
-		PublishAllPorts: true,
+	labels := maps.Clone(t.Labels)
+	if labels == nil {
+		labels = make(map[string]string)
 	}
-	resp, err := d.Client.ContainerCreate(ctx, &cc, &hc, nil, nil, d.Config.Name)
-	if err != nil {
-		d.Logger.Error("Error creating container using image", slog.Any("image", d.Config.Image), slog.Any("error", err))
-		return DockerResult{Error: err}
-	}
-
-	err = d.Client.ContainerStart(ctx, resp.ID, container.StartOptions{})
-	if err != nil {
-		d.Logger.Error("Error starting container", slog.Any("ID", resp.ID), slog.Any("error", err))
-		return DockerResult{Error: err}
+	labels[LabelTaskID] = t.ID.String()
+	bindings := t.portBindings()
+	exposed := maps.Clone(t.ExposedPorts)
+	for p := range bindings {
+		if exposed == nil {
+			exposed = nat.PortSet{}
+		}
+		exposed[p] = struct{}{}
 	}
-
-	d.Logger.Info("Container created", slog.Any("ID", resp.ID))
-
-	return DockerResult{ContainerID: resp.ID, Action: "start", Result: "success"}
-}
-
-func (d *Docker) Stop(id string) DockerResult {
-	log.Printf("Attempting to stop container %v", id)
-	ctx := context.Background()
-	err := d.Client.ContainerStop(ctx, id, container.StopOptions{})
-	if err != nil {
-		log.Printf("Error stopping container %s: %v\n", id, err)
-		return DockerResult{Error: err}
+	// The port of a health check has to be published for the worker to reach it.
+	if h := t.HealthCheck; h != nil && (h.HTTP || h.TCP) {
+		if exposed == nil {
+			exposed = nat.PortSet{}
+		}
+		exposed[h.ContainerPort()] = struct{}{}
 	}
-
-	err = d.Client.ContainerRemove(ctx, id, container.RemoveOptions{
-		RemoveVolumes: true,
-		RemoveLinks:   false,
-		Force:         false,
-	})
-	if err != nil {
-		log.Printf("Error removing container %s: %v\n", id, err)
-		return DockerResult{Error: err}
+	// Only the ports of the task are published, those without a host port on
+	// a random one; the other ports the image exposes are not.
+	for p := range exposed {
+		if bindings == nil {
+			bindings = nat.PortMap{}
+		}
+		if _, ok := bindings[p]; !ok {
+			bindings[p] = []nat.PortBinding{{}}
+		}
 	}
-
-	return DockerResult{Action: "stop", Result: "success", Error: nil}
-}
-
-func (d *Docker) Inspect(containerID string) DockerInspectResponse {
-	dc, _ := client.NewClientWithOpts(client.FromEnv)
-	ctx := context.Background()
-	resp, err := dc.ContainerInspect(ctx, containerID)
-	if err != nil {
-		log.Printf("Error inspecting container: %s\n", err)
-		return DockerInspectResponse{Error: err}
+	return Config{
+		Name:         t.Name,
+		AttachStdin:  false,
+		AttachStdout: false,
+		AttachStderr: false,
+		ExposedPorts: exposed,
+		PortBindings: bindings,
+		Cmd:          t.Cmd,
+		Image:        t.Image,
+		CPU:          t.CPU,
+		Memory:       t.Memory,
+		Disk:         t.Disk,
+		Env:          t.Env,
+		Labels:       labels,
 	}
-
-	return DockerInspectResponse{Container: &resp}
-}
-
-type DockerResult struct {
-	Error       error
-	Action      string
-	ContainerID string
-	Result      string
 }
 
 var stateTransitionMap = map[State][]State{
 	Pending:   {Scheduled},
 	Scheduled: {Scheduled, Running, Failed},
 	Running:   {Running, Completed, Failed},
-	Completed: {},
-	Failed:    {},
+	// A stopped task starts again when the manager restarts it on the same
+	// worker.
+	Completed: {Scheduled},
+	Failed:    {Scheduled},
 }
 
 func Contains(states []State, state State) bool {
@@ -208,8 +186,3 @@ func Contains(states []State, state State) bool {
 func ValidStateTransition(src State, dst State) bool {
 	return Contains(stateTransitionMap[src], dst)
 }
-
-type DockerInspectResponse struct {
-	Error     error
-	Container *container.InspectResponse
-}
@@ -30,6 +30,12 @@ func (a *API) CollectStats(w http.ResponseWriter, r *http.Request) {
 	_ = json.NewEncoder(w).Encode(a.Worker.CollectStats())
 }
 
+func (a *API) InfoHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(a.Worker.Info())
+}
+
 func (a *API) StartTaskHandler(w http.ResponseWriter, r *http.Request) {
 	d := json.NewDecoder(r.Body)
 	d.DisallowUnknownFields()
@@ -69,13 +75,12 @@ func (a *API) StopTaskHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	tID, _ := uuid.Parse(taskID)
-	_, ok := a.Worker.DB[tID]
+	taskToStop, ok := a.Worker.GetTask(tID)
 	if !ok {
 		log.Printf("No task with ID %v found", tID)
 		w.WriteHeader(http.StatusNotFound)
 		return
 	}
-	taskToStop := a.Worker.DB[tID]
 	taskCopy := *taskToStop
 	taskCopy.State = task.Completed
 	a.Worker.AddTask(taskCopy)
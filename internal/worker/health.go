@@ -0,0 +1,178 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/go-connections/nat"
+	"github.com/google/uuid"
+	"github.com/nduyhai/maestro/internal/task"
+)
+
+// healthState tracks the health checks of the container of a running task.
+type healthState struct {
+	containerID string
+	next        time.Time
+	failures    int
+}
+
+// CheckHealth runs the health checks that are due on the running tasks. A
+// task that fails as many checks in a row as its threshold, not counting
+// those within its start period, is stopped and marked failed, which leaves
+// restarting it to the manager.
+func (w *Worker) CheckHealth(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, t := range w.dueHealthChecks(time.Now()) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.checkHealth(ctx, t)
+		}()
+	}
+	wg.Wait()
+}
+
+// dueHealthChecks returns the running tasks whose health check is due and
+// schedules their next check.
+func (w *Worker) dueHealthChecks(now time.Time) []task.Task {
+	w.healthMu.Lock()
+	defer w.healthMu.Unlock()
+
+	var due []task.Task
+	running := make(map[uuid.UUID]bool)
+	for _, t := range w.GetTasks() {
+		if t.State != task.Running || t.HealthCheck == nil {
+			continue
+		}
+		running[t.ID] = true
+		s, ok := w.health[t.ID]
+		if !ok || s.containerID != t.ContainerID {
+			s = &healthState{containerID: t.ContainerID, next: now}
+			w.health[t.ID] = s
+		}
+		if now.Before(s.next) {
+			continue
+		}
+		s.next = now.Add(t.HealthCheck.WithDefaults().Interval)
+		due = append(due, *t)
+	}
+	for id := range w.health {
+		if !running[id] {
+			delete(w.health, id)
+		}
+	}
+	return due
+}
+
+func (w *Worker) checkHealth(ctx context.Context, t task.Task) {
+	h := t.HealthCheck.WithDefaults()
+	probeCtx, cancel := context.WithTimeout(ctx, h.Timeout)
+	err := w.probe(probeCtx, t, h)
+	cancel()
+
+	starting := time.Since(t.StartTime) < h.StartPeriod
+	w.healthMu.Lock()
+	failures := 0
+	if s, ok := w.health[t.ID]; ok {
+		if err != nil && !starting {
+			s.failures++
+		} else {
+			s.failures = 0
+		}
+		failures = s.failures
+	}
+	w.healthMu.Unlock()
+
+	unhealthy := err != nil && failures >= h.FailureThreshold
+	if err != nil {
+		w.Logger.Info("Health check failed", slog.Any("taskID", t.ID), slog.Int("failures", failures), slog.Any("error", err))
+	}
+	saved := w.updateTask(t.ID, func(current *task.Task) bool {
+		if current.State != task.Running || current.ContainerID != t.ContainerID {
+			return false
+		}
+		switch {
+		case unhealthy:
+			current.Health = task.HealthUnhealthy
+			current.State = task.Failed
+			current.FinishTime = time.Now().UTC()
+			current.ExitReason = fmt.Sprintf("unhealthy: %v", err)
+			return true
+		case err == nil && current.Health != task.HealthHealthy:
+			current.Health = task.HealthHealthy
+			return true
+		default:
+			return false
+		}
+	})
+	if saved && unhealthy {
+		w.Logger.Error("Stopping unhealthy task", slog.Any("taskID", t.ID), slog.Any("error", err))
+		w.Runtime.Stop(ctx, t.ContainerID)
+	}
+}
+
+// probe runs the health check h once against t.
+func (w *Worker) probe(ctx context.Context, t task.Task, h task.HealthCheck) error {
+	if len(h.Exec) > 0 {
+		code, err := w.Runtime.Exec(ctx, t.ContainerID, h.Exec)
+		if err != nil {
+			return err
+		}
+		if code != 0 {
+			return fmt.Errorf("%s exited with code %d", strings.Join(h.Exec, " "), code)
+		}
+		return nil
+	}
+
+	addr, err := w.hostAddr(ctx, t, h.ContainerPort())
+	if err != nil {
+		return err
+	}
+	if h.TCP {
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+addr+h.Path, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		return fmt.Errorf("GET %s returned %s", h.Path, resp.Status)
+	}
+	return nil
+}
+
+// hostAddr returns the address the container port p of t is published on,
+// inspecting the container when the task has not recorded it yet.
+func (w *Worker) hostAddr(ctx context.Context, t task.Task, p nat.Port) (string, error) {
+	bindings := t.HostPorts[p]
+	if len(bindings) == 0 {
+		if resp := w.InspectTask(ctx, t); resp.Container != nil && resp.Container.NetworkSettings != nil {
+			bindings = resp.Container.NetworkSettings.Ports[p]
+		}
+	}
+	if len(bindings) == 0 {
+		return "", fmt.Errorf("port %s is not published", p)
+	}
+	host := bindings[0].HostIP
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "localhost"
+	}
+	return net.JoinHostPort(host, bindings[0].HostPort), nil
+}
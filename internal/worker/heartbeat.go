@@ -0,0 +1,60 @@
+package worker
+
+import (
+	"time"
+
+	"github.com/docker/go-connections/nat"
+	"github.com/google/uuid"
+	"github.com/nduyhai/maestro/internal/stats"
+	"github.com/nduyhai/maestro/internal/task"
+	"github.com/samber/lo"
+)
+
+// Registration announces a worker, reachable at URL, to the manager.
+type Registration struct {
+	Info
+	URL   string
+	Stats *stats.Stats
+}
+
+// Heartbeat is sent periodically by a registered worker.
+type Heartbeat struct {
+	Stats *stats.Stats
+	Tasks []TaskSummary
+}
+
+// TaskSummary is the state of a task as last seen by its worker.
+type TaskSummary struct {
+	ID          uuid.UUID
+	State       task.State
+	ContainerID string
+	StartTime   time.Time
+	FinishTime  time.Time
+	HostPorts   nat.PortMap
+	ExitReason  string
+	Health      task.Health
+}
+
+func Summarize(t *task.Task) TaskSummary {
+	return TaskSummary{
+		ID:          t.ID,
+		State:       t.State,
+		ContainerID: t.ContainerID,
+		StartTime:   t.StartTime,
+		FinishTime:  t.FinishTime,
+		HostPorts:   t.HostPorts,
+		ExitReason:  t.ExitReason,
+		Health:      t.Health,
+	}
+}
+
+// Registration describes w to the manager as reachable at url.
+func (w *Worker) Registration(url string) Registration {
+	s := w.CollectStats()
+	return Registration{Info: w.Info(), URL: url, Stats: &s}
+}
+
+func (w *Worker) Heartbeat() Heartbeat {
+	s := w.CollectStats()
+	return Heartbeat{Stats: &s, Tasks: lo.Map(w.GetTasks(), func(t *task.Task, _ int) TaskSummary { return Summarize(t) })}
+}
@@ -0,0 +1,78 @@
+package worker
+
+import (
+	"context"
+	"hash/fnv"
+	"sync"
+)
+
+// Queue holds operations waiting to be executed, spread over shards that are
+// each drained by a single executor. All operations of a task land in the same
+// shard so they run one at a time and in the order they were queued.
+type Queue struct {
+	shards []*shard
+}
+
+type shard struct {
+	mu     sync.Mutex
+	items  []Operation
+	notify chan struct{}
+}
+
+func NewQueue(shards int) *Queue {
+	q := &Queue{shards: make([]*shard, max(shards, 1))}
+	for i := range q.shards {
+		q.shards[i] = &shard{notify: make(chan struct{}, 1)}
+	}
+	return q
+}
+
+func (q *Queue) Enqueue(op Operation) {
+	h := fnv.New32a()
+	_, _ = h.Write(op.Task.ID[:])
+	s := q.shards[h.Sum32()%uint32(len(q.shards))]
+
+	s.mu.Lock()
+	s.items = append(s.items, op)
+	s.mu.Unlock()
+
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Dequeue blocks until an operation is available in shard i or ctx is done.
+func (q *Queue) Dequeue(ctx context.Context, i int) (Operation, bool) {
+	s := q.shards[i]
+	for {
+		s.mu.Lock()
+		if len(s.items) > 0 {
+			op := s.items[0]
+			s.items = s.items[1:]
+			s.mu.Unlock()
+			return op, true
+		}
+		s.mu.Unlock()
+
+		select {
+		case <-s.notify:
+		case <-ctx.Done():
+			return Operation{}, false
+		}
+	}
+}
+
+func (q *Queue) Size() int {
+	var n int
+	for _, s := range q.shards {
+		s.mu.Lock()
+		n += len(s.items)
+		s.mu.Unlock()
+	}
+	return n
+}
+
+func (q *Queue) Shards() int {
+	return len(q.shards)
+}
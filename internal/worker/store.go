@@ -0,0 +1,38 @@
+package worker
+
+import (
+	"github.com/nduyhai/maestro/internal/store"
+	"github.com/nduyhai/maestro/internal/task"
+	"go.etcd.io/bbolt"
+)
+
+// Operation is a task state change waiting in the worker queue.
+type Operation struct {
+	Key  string
+	Task task.Task
+}
+
+// Store is the durable state of a worker.
+type Store struct {
+	Tasks store.Store[task.Task]
+	Queue store.Store[Operation]
+}
+
+func NewBoltStore(db *bbolt.DB) (*Store, error) {
+	tasks, err := store.NewBolt[task.Task](db, "tasks")
+	if err != nil {
+		return nil, err
+	}
+	queue, err := store.NewBolt[Operation](db, "queue")
+	if err != nil {
+		return nil, err
+	}
+	return &Store{Tasks: tasks, Queue: queue}, nil
+}
+
+func NewMemoryStore() *Store {
+	return &Store{
+		Tasks: store.NewMemory[task.Task](),
+		Queue: store.NewMemory[Operation](),
+	}
+}
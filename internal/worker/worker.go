@@ -1,73 +1,127 @@
 package worker
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log"
 	"log/slog"
-	"maps"
-	"slices"
+	"sync"
 	"time"
 
+	"github.com/docker/docker/api/types/container"
 	"github.com/go-chi/httplog/v2"
 
 	"github.com/samber/lo"
-	"github.com/shirou/gopsutil/v4/cpu"
-	"github.com/shirou/gopsutil/v4/disk"
-	"github.com/shirou/gopsutil/v4/load"
-	"github.com/shirou/gopsutil/v4/mem"
 
+	"github.com/nduyhai/maestro/internal/loop"
+	"github.com/nduyhai/maestro/internal/stats"
 	"github.com/nduyhai/maestro/internal/task"
 
-	"github.com/emirpasic/gods/queues"
 	"github.com/google/uuid"
 )
 
 type Worker struct {
 	Name      string
-	Queue     queues.Queue
+	Zone      string
+	Rack      string
+	Labels    map[string]string
+	Taints    []task.Taint
+	Queue     *Queue
 	DB        map[uuid.UUID]*task.Task
 	TaskCount int
+	Runtime   task.Runtime
+	Store     *Store
 	Logger    *httplog.Logger
-}
 
-type Stats struct {
-	Memory *mem.VirtualMemoryStat
-	CPU    []cpu.InfoStat
-	Disk   *disk.UsageStat
-	Load   *load.AvgStat
+	// mu guards DB and orders writes of tasks to the store.
+	mu      sync.RWMutex
+	keyMu   sync.Mutex
+	lastKey int64
+
+	healthMu sync.Mutex
+	health   map[uuid.UUID]*healthState
 }
 
-func (w *Worker) CollectStats() Stats {
-	memory, _ := mem.VirtualMemory()
-	info, _ := cpu.Info()
-	usage, _ := disk.Usage("/")
-	avg, _ := load.Avg()
-	return Stats{
-		Memory: memory,
-		CPU:    info,
-		Disk:   usage,
-		Load:   avg,
+// NewWorker returns a worker that executes up to concurrency operations at once.
+func NewWorker(runtime task.Runtime, store *Store, concurrency int, logger *httplog.Logger) *Worker {
+	return &Worker{
+		Queue:   NewQueue(concurrency),
+		DB:      make(map[uuid.UUID]*task.Task),
+		Runtime: runtime,
+		Store:   store,
+		Logger:  logger,
+		health:  make(map[uuid.UUID]*healthState),
 	}
+}
 
+// Info describes a worker node to the manager.
+type Info struct {
+	Name   string
+	Zone   string
+	Rack   string
+	Labels map[string]string
+	Taints []task.Taint
 }
 
-func (w *Worker) RunTask() task.DockerResult {
-	t, ok := w.Queue.Dequeue()
-	if !ok {
-		w.Logger.Error("no tasks in the queue")
-		return task.DockerResult{Error: nil}
+func (w *Worker) Info() Info {
+	return Info{Name: w.Name, Zone: w.Zone, Rack: w.Rack, Labels: w.Labels, Taints: w.Taints}
+}
+
+func (w *Worker) CollectStats() stats.Stats {
+	return stats.Collect()
+}
+
+// Run starts one executor per queue shard, the loop that syncs task state
+// from the runtime every updateInterval and the one running the health checks
+// that are due every healthCheckInterval. They stop when g stops.
+func (w *Worker) Run(g *loop.Group, updateInterval, healthCheckInterval time.Duration) error {
+	for i := range w.Queue.Shards() {
+		g.Go(fmt.Sprintf("executor-%d", i), func(ctx context.Context) {
+			for {
+				op, ok := w.Queue.Dequeue(ctx, i)
+				if !ok {
+					return
+				}
+				result := w.RunTask(ctx, op)
+				if result.Error != nil {
+					w.Logger.Error("Error running task", slog.Any("taskID", op.Task.ID), slog.Any("error", result.Error))
+				}
+			}
+		})
 	}
+	return errors.Join(
+		g.Every("update-tasks", updateInterval, nil, w.UpdateTasks),
+		g.Every("health-checks", healthCheckInterval, nil, w.CheckHealth),
+	)
+}
 
-	taskQueued, ok := t.(task.Task)
+func (w *Worker) RunTask(ctx context.Context, op Operation) task.DockerResult {
+	defer func() {
+		// An operation interrupted by shutdown stays persisted and is retried on restart.
+		if ctx.Err() != nil {
+			return
+		}
+		if err := w.Store.Queue.Delete(op.Key); err != nil {
+			w.Logger.Error("Error removing queued operation", slog.Any("key", op.Key), slog.Any("error", err))
+		}
+	}()
+
+	taskQueued := op.Task
+	taskPersisted, ok := w.GetTask(taskQueued.ID)
 	if !ok {
-		w.Logger.Error("error during task queue")
-		return task.DockerResult{Error: nil}
-	}
-	taskPersisted := w.DB[taskQueued.ID]
-	if taskPersisted == nil {
 		taskPersisted = &taskQueued
-		w.DB[taskQueued.ID] = &taskQueued
+		w.saveTask(&taskQueued)
+	}
+	if taskQueued.ContainerID == "" {
+		taskQueued.ContainerID = taskPersisted.ContainerID
+	}
+
+	// A stopped task asked to stop again only has its exited container
+	// removed, as the manager does before restarting it elsewhere.
+	stopped := taskPersisted.State == task.Failed || taskPersisted.State == task.Completed
+	if stopped && taskQueued.State == task.Completed {
+		return w.removeContainer(ctx, *taskPersisted)
 	}
 
 	var result task.DockerResult
@@ -75,9 +129,14 @@ func (w *Worker) RunTask() task.DockerResult {
 		taskPersisted.State, taskQueued.State) {
 		switch taskQueued.State {
 		case task.Scheduled:
-			result = w.StartTask(taskQueued)
+			// A task restarted on this worker replaces its old container.
+			if stopped && taskPersisted.ContainerID != "" {
+				w.Runtime.Stop(ctx, taskPersisted.ContainerID)
+				taskQueued.ContainerID = ""
+			}
+			result = w.StartTask(ctx, taskQueued)
 		case task.Completed:
-			result = w.StopTask(taskQueued)
+			result = w.StopTask(ctx, taskQueued)
 		default:
 			result.Error = errors.New("we should not get here")
 		}
@@ -88,88 +147,292 @@ func (w *Worker) RunTask() task.DockerResult {
 	return result
 }
 
-func (w *Worker) StartTask(t task.Task) task.DockerResult {
+func (w *Worker) StartTask(ctx context.Context, t task.Task) task.DockerResult {
 	w.Logger.Info("I will start a task")
 	t.StartTime = time.Now().UTC()
 	config := task.NewConfig(&t)
-	d := task.NewDocker(config, w.Logger)
-	result := d.Run()
+	result := w.Runtime.Run(ctx, config)
 	if result.Error != nil {
 		w.Logger.Error("Err running task", slog.Any("error", result.Error), slog.Any("taskID", t.ID))
 		t.State = task.Failed
-		w.DB[t.ID] = &t
+		t.FinishTime = time.Now().UTC()
+		t.ExitReason = fmt.Sprintf("start failed: %v", result.Error)
+		w.saveTask(&t)
 		return result
 	}
 
 	t.ContainerID = result.ContainerID
 	t.State = task.Running
-	w.DB[t.ID] = &t
+	t.Health = ""
+	if t.HealthCheck != nil {
+		t.Health = task.HealthStarting
+	}
+	w.saveTask(&t)
 
 	return result
 }
 
-func (w *Worker) StopTask(t task.Task) task.DockerResult {
+func (w *Worker) StopTask(ctx context.Context, t task.Task) task.DockerResult {
 	w.Logger.Info("I will stop a task")
-	config := task.NewConfig(&t)
-	d := task.NewDocker(config, w.Logger)
-
-	result := d.Stop(t.ContainerID)
+	result := w.Runtime.Stop(ctx, t.ContainerID)
 	if result.Error != nil {
 		w.Logger.Error("Error stopping container", slog.Any("ContainerID", t.ContainerID), slog.Any("error", result.Error))
 	}
 	t.FinishTime = time.Now().UTC()
 	t.State = task.Completed
-	w.DB[t.ID] = &t
-	d.Logger.Info("Stopped task", slog.Any("ContainerID", t.ContainerID), slog.Any("taskID", t.ID))
+	t.ExitReason = task.ReasonStopped
+	w.saveTask(&t)
+	w.Logger.Info("Stopped task", slog.Any("ContainerID", t.ContainerID), slog.Any("taskID", t.ID))
 
 	return result
 }
 
+// removeContainer removes the container of a task that has stopped running,
+// keeping the state and exit reason of the task.
+func (w *Worker) removeContainer(ctx context.Context, t task.Task) task.DockerResult {
+	if t.ContainerID == "" {
+		return task.DockerResult{Action: "stop", Result: "success"}
+	}
+	result := w.Runtime.Stop(ctx, t.ContainerID)
+	if result.Error != nil && !errors.Is(result.Error, task.ErrContainerNotFound) {
+		w.Logger.Error("Error removing container", slog.Any("ContainerID", t.ContainerID), slog.Any("error", result.Error))
+		return result
+	}
+	w.Logger.Info("Removed container of stopped task", slog.Any("ContainerID", t.ContainerID), slog.Any("taskID", t.ID))
+	t.ContainerID = ""
+	w.saveTask(&t)
+	return task.DockerResult{Action: "stop", Result: "success"}
+}
+
 func (w *Worker) AddTask(t task.Task) {
-	w.Queue.Enqueue(t)
+	op := Operation{Key: w.nextKey(), Task: t}
+	if err := w.Store.Queue.Put(op.Key, op); err != nil {
+		w.Logger.Error("Error persisting queued operation", slog.Any("taskID", t.ID), slog.Any("error", err))
+	}
+	w.Queue.Enqueue(op)
 }
 
-func (w *Worker) GetTasks() []*task.Task {
-	tasks, _ := lo.CoalesceSlice(slices.Collect(maps.Values(w.DB)), []*task.Task{})
-	return tasks
+// nextKey returns a queue key that sorts after every key handed out before.
+func (w *Worker) nextKey() string {
+	w.keyMu.Lock()
+	defer w.keyMu.Unlock()
+	w.lastKey = max(time.Now().UnixNano(), w.lastKey+1)
+	return fmt.Sprintf("%020d", w.lastKey)
 }
 
-func (w *Worker) InspectTask(t task.Task) task.DockerInspectResponse {
-	config := task.NewConfig(&t)
-	d := task.NewDocker(config, w.Logger)
-	return d.Inspect(t.ContainerID)
+// GetTask returns a copy of the task with the given id.
+func (w *Worker) GetTask(id uuid.UUID) (*task.Task, bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	t, ok := w.DB[id]
+	if !ok {
+		return nil, false
+	}
+	c := *t
+	return &c, true
 }
 
-func (w *Worker) UpdateTasks() {
-	for {
-		log.Println("Checking status of tasks")
-		w.updateTasks()
-		log.Println("Task updates completed")
-		log.Println("Sleeping for 15 seconds")
-		time.Sleep(15 * time.Second)
+func (w *Worker) saveTask(t *task.Task) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.putTask(*t)
+}
+
+// updateTask applies fn to the current version of a task and saves the result
+// unless fn returns false. It reports whether the task was saved.
+func (w *Worker) updateTask(id uuid.UUID, fn func(t *task.Task) bool) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	current, ok := w.DB[id]
+	if !ok {
+		return false
 	}
+	t := *current
+	if !fn(&t) {
+		return false
+	}
+	w.putTask(t)
+	return true
 }
 
-func (w *Worker) updateTasks() {
-	for id, t := range w.DB {
-		if t.State == task.Running {
-			resp := w.InspectTask(*t)
-			if resp.Error != nil {
-				fmt.Printf("ERROR: %v\n", resp.Error)
-			}
+// putTask stores t in memory and on disk; callers must hold w.mu.
+func (w *Worker) putTask(t task.Task) {
+	w.DB[t.ID] = &t
+	if err := w.Store.Tasks.Put(t.ID.String(), t); err != nil {
+		w.Logger.Error("Error persisting task", slog.Any("taskID", t.ID), slog.Any("error", err))
+	}
+}
 
+// GetTasks returns a snapshot of every task known to the worker.
+func (w *Worker) GetTasks() []*task.Task {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return lo.MapToSlice(w.DB, func(_ uuid.UUID, t *task.Task) *task.Task {
+		c := *t
+		return &c
+	})
+}
+
+func (w *Worker) InspectTask(ctx context.Context, t task.Task) task.DockerInspectResponse {
+	return w.Runtime.Inspect(ctx, t.ContainerID)
+}
+
+// UpdateTasks syncs the state of running tasks with their containers.
+func (w *Worker) UpdateTasks(ctx context.Context) {
+	w.Logger.Debug("Checking status of tasks")
+	for _, running := range w.GetTasks() {
+		if running.State != task.Running {
+			continue
+		}
+		resp := w.InspectTask(ctx, *running)
+		if resp.Error != nil && !errors.Is(resp.Error, task.ErrContainerNotFound) {
+			// The runtime could not be reached; the container may well
+			// still be running, so keep the task as it is.
+			w.Logger.Error("Error inspecting task", slog.Any("taskID", running.ID), slog.Any("error", resp.Error))
+			continue
+		}
+
+		// The task may have been stopped or restarted while its container was
+		// being inspected, in which case the result is stale.
+		w.updateTask(running.ID, func(t *task.Task) bool {
+			if t.State != task.Running || t.ContainerID != running.ContainerID {
+				return false
+			}
 			if resp.Container == nil {
-				log.Printf("No container for running task %s\n", id)
-				w.DB[id].State = task.Failed
+				log.Printf("No container for running task %s\n", t.ID)
+				t.State = task.Failed
+				t.FinishTime = time.Now().UTC()
+				t.ExitReason = "container not found"
+				return true
 			}
-
-			if resp.Container.State.Status == "exited" {
+			if state := resp.Container.State; state.Status == "exited" {
 				log.Printf("Container for task %s in non-running state %s",
-					id, resp.Container.State.Status)
-				w.DB[id].State = task.Failed
+					t.ID, state.Status)
+				setExited(t, state)
 			}
+			t.HostPorts = resp.Container.NetworkSettings.NetworkSettingsBase.Ports
+			return true
+		})
+	}
+}
+
+// setExited records that the container of t exited. A clean exit completes
+// the task; anything else is a failure.
+func setExited(t *task.Task, state *container.State) {
+	t.State = task.Completed
+	if state.ExitCode != 0 || state.OOMKilled {
+		t.State = task.Failed
+	}
+	t.FinishTime = time.Now().UTC()
+	if finished, err := time.Parse(time.RFC3339Nano, state.FinishedAt); err == nil {
+		t.FinishTime = finished
+	}
+	t.ExitReason = exitReason(state)
+}
 
-			w.DB[id].HostPorts = resp.Container.NetworkSettings.NetworkSettingsBase.Ports
+// exitReason describes how the container of a task exited.
+func exitReason(state *container.State) string {
+	reason := fmt.Sprintf("exited with code %d", state.ExitCode)
+	if state.OOMKilled {
+		reason = fmt.Sprintf("OOM killed (exit code %d)", state.ExitCode)
+	}
+	if state.Error != "" {
+		reason += ": " + state.Error
+	}
+	return reason
+}
+
+// Recover reloads persisted tasks and queued operations and reconciles them
+// against the containers present in the runtime. Running containers of known
+// tasks are adopted, tasks whose container exited are marked completed or
+// failed as its exit code says, tasks whose container is gone are marked
+// failed and containers that belong to no task, or to a completed one, are
+// removed.
+func (w *Worker) Recover(ctx context.Context) error {
+	tasks, err := w.Store.Tasks.List()
+	if err != nil {
+		return fmt.Errorf("load tasks: %w", err)
+	}
+	w.mu.Lock()
+	for _, t := range tasks {
+		w.DB[t.ID] = &t
+	}
+	w.mu.Unlock()
+
+	ops, err := w.Store.Queue.List()
+	if err != nil {
+		return fmt.Errorf("load queue: %w", err)
+	}
+	queued := make(map[uuid.UUID]bool)
+	for _, op := range ops {
+		queued[op.Task.ID] = true
+		w.Queue.Enqueue(op)
+	}
+	if len(ops) > 0 {
+		_, _ = fmt.Sscanf(ops[len(ops)-1].Key, "%d", &w.lastKey)
+	}
+
+	containers, err := w.Runtime.List(ctx)
+	if err != nil {
+		return fmt.Errorf("list containers: %w", err)
+	}
+	owned := make(map[uuid.UUID]task.ContainerSummary)
+	for _, c := range containers {
+		id, err := uuid.Parse(c.Labels[task.LabelTaskID])
+		if err != nil {
+			continue
+		}
+		if _, ok := w.GetTask(id); !ok {
+			w.Logger.Info("Removing orphaned container", slog.Any("ContainerID", c.ID), slog.Any("taskID", id))
+			w.Runtime.Stop(ctx, c.ID)
+			continue
+		}
+		owned[id] = c
+	}
+
+	current := w.GetTasks()
+	for _, t := range current {
+		id := t.ID
+		c, found := owned[id]
+		switch t.State {
+		case task.Scheduled, task.Running:
+			switch {
+			case found && c.State == string(container.StateRunning):
+				w.Logger.Info("Adopting running container", slog.Any("ContainerID", c.ID), slog.Any("taskID", id))
+				t.ContainerID = c.ID
+				t.State = task.Running
+			case found:
+				w.Logger.Info("Container of task is no longer running", slog.Any("ContainerID", c.ID), slog.Any("taskID", id), slog.String("state", c.State))
+				t.ContainerID = c.ID
+				t.State = task.Failed
+				t.FinishTime = time.Now().UTC()
+				t.ExitReason = fmt.Sprintf("container %s", c.State)
+				resp := w.Runtime.Inspect(ctx, c.ID)
+				if resp.Container != nil && resp.Container.State != nil && resp.Container.State.Status == "exited" {
+					setExited(t, resp.Container.State)
+				}
+			case t.State == task.Scheduled && queued[id]:
+				continue
+			default:
+				w.Logger.Info("No container found for task", slog.Any("taskID", id))
+				t.State = task.Failed
+				t.FinishTime = time.Now().UTC()
+				t.ExitReason = "container not found"
+			}
+			w.saveTask(t)
+		case task.Completed:
+			if found {
+				w.Logger.Info("Removing container of completed task", slog.Any("ContainerID", c.ID), slog.Any("taskID", id))
+				w.Runtime.Stop(ctx, c.ID)
+			}
+		case task.Failed:
+			if found && c.State == string(container.StateRunning) {
+				w.Logger.Info("Stopping container of failed task", slog.Any("ContainerID", c.ID), slog.Any("taskID", id))
+				w.Runtime.Stop(ctx, c.ID)
+			}
 		}
 	}
+
+	w.Logger.Info("Recovered worker state", slog.Int("tasks", len(current)), slog.Int("queued", len(ops)), slog.Int("containers", len(owned)))
+	return nil
 }
@@ -0,0 +1,236 @@
+package worker_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/httplog/v2"
+	"github.com/google/uuid"
+	"github.com/nduyhai/maestro/client"
+	"github.com/nduyhai/maestro/internal/loop"
+	"github.com/nduyhai/maestro/internal/task"
+	"github.com/nduyhai/maestro/internal/worker"
+)
+
+var logger = httplog.NewLogger("test", httplog.Options{LogLevel: slog.LevelError, Writer: io.Discard})
+
+// startWorker runs a worker on the fake runtime and serves its API.
+func startWorker(t *testing.T) (*worker.Worker, *client.Client) {
+	t.Helper()
+	w := worker.NewWorker(task.NewFake(), worker.NewMemoryStore(), 4, logger)
+	if err := w.Recover(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	g := loop.NewGroup(logger)
+	if err := w.Run(g, 10*time.Millisecond, 10*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+
+	a := worker.NewAPI(w, logger)
+	r := chi.NewRouter()
+	r.Post("/tasks", a.StartTaskHandler)
+	r.Get("/tasks", a.GetTasksHandler)
+	r.Delete("/tasks/{taskID}", a.StopTaskHandler)
+	srv := httptest.NewServer(r)
+
+	c := client.New(srv.URL)
+	t.Cleanup(func() {
+		_ = c.Close()
+		srv.Close()
+		_ = g.Stop(context.Background())
+	})
+	return w, c
+}
+
+// waitFor polls until every task is in state, or fails the test.
+func waitFor(t *testing.T, w *worker.Worker, ids []uuid.UUID, state task.State) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		pending := 0
+		for _, id := range ids {
+			if got, ok := w.GetTask(id); !ok || got.State != state {
+				pending++
+			}
+		}
+		if pending == 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d of %d tasks did not reach %s", pending, len(ids), state)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWorkerConcurrentSubmitStopList(t *testing.T) {
+	const n = 50
+	w, c := startWorker(t)
+	ctx := context.Background()
+
+	ids := make([]uuid.UUID, n)
+	for i := range ids {
+		ids[i] = uuid.New()
+	}
+
+	// Listers run throughout, against the API and the worker directly.
+	done := make(chan struct{})
+	var listers sync.WaitGroup
+	for range 4 {
+		listers.Add(1)
+		go func() {
+			defer listers.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				if _, err := c.ListTasks(ctx); err != nil {
+					t.Error(err)
+					return
+				}
+				_ = w.GetTasks()
+				_ = w.Heartbeat()
+			}
+		}()
+	}
+
+	var wg sync.WaitGroup
+	for _, id := range ids {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			te := task.Event{ID: uuid.New(), State: task.Scheduled, Task: task.Task{ID: id, Name: id.String(), Image: "nginx", State: task.Scheduled}}
+			if _, err := c.SubmitTask(ctx, te); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	waitFor(t, w, ids, task.Running)
+
+	for _, id := range ids {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := c.StopTask(ctx, id); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	waitFor(t, w, ids, task.Completed)
+
+	close(done)
+	listers.Wait()
+
+	tasks, err := c.ListTasks(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != n {
+		t.Fatalf("got %d tasks, want %d", len(tasks), n)
+	}
+}
+
+func TestWorkerConcurrentEnqueue(t *testing.T) {
+	const n = 100
+	w, _ := startWorker(t)
+
+	ids := make([]uuid.UUID, n)
+	var wg sync.WaitGroup
+	for i := range ids {
+		ids[i] = uuid.New()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.AddTask(task.Task{ID: ids[i], Name: ids[i].String(), Image: "nginx", State: task.Scheduled})
+			_ = w.GetTasks()
+		}()
+	}
+	wg.Wait()
+	waitFor(t, w, ids, task.Running)
+
+	for _, id := range ids {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			running, ok := w.GetTask(id)
+			if !ok {
+				t.Errorf("task %s not found", id)
+				return
+			}
+			stop := *running
+			stop.State = task.Completed
+			w.AddTask(stop)
+		}()
+	}
+	wg.Wait()
+	waitFor(t, w, ids, task.Completed)
+}
+
+func TestWorkerRecover(t *testing.T) {
+	ctx := context.Background()
+	rt := task.NewFake()
+	store := worker.NewMemoryStore()
+
+	// A first worker starts the tasks, then their containers change while
+	// no worker is running.
+	before := worker.NewWorker(rt, store, 1, logger)
+	start := func(name string) task.Task {
+		t.Helper()
+		tk := task.Task{ID: uuid.New(), Name: name, Image: "nginx", State: task.Scheduled}
+		if result := before.StartTask(ctx, tk); result.Error != nil {
+			t.Fatal(result.Error)
+		}
+		started, _ := before.GetTask(tk.ID)
+		return *started
+	}
+	running, exited, failed, missing := start("running"), start("exited"), start("failed"), start("missing")
+	if err := rt.Exit(exited.ContainerID, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := rt.Exit(failed.ContainerID, 3); err != nil {
+		t.Fatal(err)
+	}
+	if result := rt.Stop(ctx, missing.ContainerID); result.Error != nil {
+		t.Fatal(result.Error)
+	}
+
+	after := worker.NewWorker(rt, store, 1, logger)
+	if err := after.Recover(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		task   task.Task
+		state  task.State
+		reason string
+	}{
+		{task: running, state: task.Running},
+		{task: exited, state: task.Completed, reason: "exited with code 0"},
+		{task: failed, state: task.Failed, reason: "exited with code 3"},
+		{task: missing, state: task.Failed, reason: "container not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.task.Name, func(t *testing.T) {
+			got, ok := after.GetTask(tt.task.ID)
+			if !ok {
+				t.Fatal("task not recovered")
+			}
+			if got.State != tt.state || got.ExitReason != tt.reason {
+				t.Fatalf("got %s (%q), want %s (%q)", got.State, got.ExitReason, tt.state, tt.reason)
+			}
+			if tt.state == task.Running && got.ContainerID != tt.task.ContainerID {
+				t.Fatalf("adopted container %s, want %s", got.ContainerID, tt.task.ContainerID)
+			}
+		})
+	}
+}
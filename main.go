@@ -2,83 +2,70 @@ package main
 
 import (
 	"context"
-	"go.etcd.io/bbolt"
-	"log/slog"
+	"errors"
+	"flag"
+	"fmt"
 	"net/http"
+	"os"
+	"path/filepath"
 	"time"
 
-	"github.com/nduyhai/maestro/internal/manager"
-	"github.com/samber/lo"
-	"resty.dev/v3"
-
-	"github.com/emirpasic/gods/queues/arrayqueue"
-
-	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/httplog/v2"
-	"github.com/nduyhai/maestro/internal/server"
-	"github.com/nduyhai/maestro/internal/task"
-	"github.com/nduyhai/maestro/internal/worker"
+	"github.com/nduyhai/maestro/internal/config"
+	"go.etcd.io/bbolt"
 	"go.uber.org/fx"
-
-	"github.com/google/uuid"
+	"resty.dev/v3"
 )
 
-func main() {
-	logger := NewLogger()
-	db := make(map[uuid.UUID]*task.Task)
-	w := worker.Worker{
-		Queue:  arrayqueue.New(),
-		DB:     db,
-		Logger: logger,
-	}
+const usage = `Usage: maestro <command> [flags]
 
-	fx.New(
-		fx.Supply(logger),
-		fx.Supply(&w),
-		fx.Provide(worker.NewAPI),
-		fx.Provide(manager.NewManager),
-		fx.Provide(manager.NewAPI),
+Commands:
+  manager   run the manager API and scheduler
+  worker    run a worker that executes tasks
 
-		fx.Provide(NewBolt),
+Run 'maestro <command> -h' for the flags of a command.
+`
 
-		fx.Provide(NewResty),
-		fx.Provide(NewWorkers),
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
 
-		fx.Provide(fx.Annotate(NewRoute, fx.As(new(http.Handler)))),
-		fx.Invoke(server.RegisterRoutes),
-		fx.Invoke(runTasks),
-	).Run()
+	switch os.Args[1] {
+	case "manager":
+		cfg, err := config.LoadManager(os.Args[2:])
+		if err != nil {
+			exit(err)
+		}
+		newManagerApp(cfg).Run()
+	case "worker":
+		cfg, err := config.LoadWorker(os.Args[2:])
+		if err != nil {
+			exit(err)
+		}
+		newWorkerApp(cfg).Run()
+	case "-h", "-help", "--help", "help":
+		fmt.Fprint(os.Stdout, usage)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
 }
 
-func NewRoute(logger *httplog.Logger, workerApi *worker.API, managerApi *manager.API) *chi.Mux {
-	r := chi.NewRouter()
-	r.Use(middleware.RealIP)
-	r.Use(httplog.RequestLogger(logger))
-	r.Use(middleware.Timeout(60 * time.Second))
-
-	r.Get("/greeting", func(w http.ResponseWriter, r *http.Request) {
-		_, _ = w.Write([]byte("welcome"))
-	})
-
-	r.Post("/tasks", workerApi.StartTaskHandler)
-	r.Get("/tasks", workerApi.GetTasksHandler)
-	r.Delete("/tasks/{taskID}", workerApi.StopTaskHandler)
-	r.Get("/stats", workerApi.CollectStats)
-
-	r.Route("/manager", func(r chi.Router) {
-		r.Post("/tasks", managerApi.StartTaskHandler)
-		r.Get("/tasks", managerApi.GetTasksHandler)
-		r.Delete("/tasks/{taskID}", managerApi.StopTaskHandler)
-	})
-
-	return r
+func exit(err error) {
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(2)
 }
 
-func NewLogger() *httplog.Logger {
+func NewLogger(level string) *httplog.Logger {
+	logLevel, _ := config.ParseLevel(level)
 	return httplog.NewLogger("maestro", httplog.Options{
 		JSON:             true,
-		LogLevel:         slog.LevelDebug,
+		LogLevel:         logLevel,
 		Concise:          true,
 		RequestHeaders:   true,
 		MessageFieldName: "message",
@@ -95,28 +82,8 @@ func NewLogger() *httplog.Logger {
 	})
 }
 
-func runTasks(lifecycle fx.Lifecycle, w *worker.Worker, logger *httplog.Logger) {
-	lifecycle.Append(fx.Hook{
-		OnStart: func(ctx context.Context) error {
-			logger.Info("starting tasks")
-			go func() {
-				for {
-					if w.Queue.Size() != 0 {
-						result := w.RunTask()
-						if result.Error != nil {
-							logger.Error("Error running task:", slog.Any("error", result.Error))
-						} else {
-							logger.Info("No tasks to process currently.")
-						}
-						logger.Info("Sleeping for 10 seconds.")
-						time.Sleep(10 * time.Second)
-					}
-				}
-			}()
-			return nil
-		},
-	})
-
+func health(w http.ResponseWriter, _ *http.Request) {
+	_, _ = w.Write([]byte("ok"))
 }
 
 func NewResty(lifecycle fx.Lifecycle) *resty.Client {
@@ -129,16 +96,12 @@ func NewResty(lifecycle fx.Lifecycle) *resty.Client {
 	return client
 }
 
-func NewWorkers() []string {
-	return lo.Map(lo.Range(4), func(item int, index int) string {
-		return "localhost:8080"
-	})
-}
-
-func NewBolt(lifecycle fx.Lifecycle) (*bbolt.DB, error) {
-	db, err := bbolt.Open("maestro.db", 0600, nil)
+func openBolt(lifecycle fx.Lifecycle, dataDir string, name string) (*bbolt.DB, error) {
+	if err := os.MkdirAll(dataDir, 0o750); err != nil {
+		return nil, err
+	}
+	db, err := bbolt.Open(filepath.Join(dataDir, name), 0600, nil)
 	if err != nil {
-
 		return nil, err
 	}
 	lifecycle.Append(fx.Hook{
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/httplog/v2"
+	"github.com/nduyhai/maestro/internal/config"
+	"github.com/nduyhai/maestro/internal/loop"
+	"github.com/nduyhai/maestro/internal/manager"
+	"github.com/nduyhai/maestro/internal/scheduler"
+	"github.com/nduyhai/maestro/internal/server"
+	"go.etcd.io/bbolt"
+	"go.uber.org/fx"
+)
+
+func newManagerApp(cfg config.Manager) *fx.App {
+	return fx.New(
+		fx.Supply(cfg),
+		fx.Supply(server.Config{Addr: cfg.Addr}),
+		fx.Supply(NewLogger(cfg.LogLevel)),
+		fx.Supply(cfg.Workers),
+		fx.Supply(manager.Config{
+			Preemption:    cfg.Preemption,
+			PriorityAging: cfg.PriorityAging,
+			NodeTimeout:   cfg.NodeTimeout,
+			LostTimeout:   cfg.LostTimeout,
+
+			RestartBackoff:    cfg.RestartBackoff,
+			MaxRestartBackoff: cfg.MaxRestartBackoff,
+		}),
+		fx.Provide(NewScheduler),
+		fx.Provide(manager.NewManager),
+		fx.Provide(manager.NewAPI),
+
+		fx.Provide(NewManagerBolt),
+		fx.Provide(manager.NewBoltStore),
+
+		fx.Provide(NewResty),
+
+		fx.Provide(fx.Annotate(NewManagerRoute, fx.As(new(http.Handler)))),
+		fx.Invoke(server.RegisterRoutes),
+		fx.Invoke(runManagerLoops),
+	)
+}
+
+func NewManagerRoute(logger *httplog.Logger, managerApi *manager.API) *chi.Mux {
+	r := chi.NewRouter()
+	r.Use(middleware.RealIP)
+	r.Use(httplog.RequestLogger(logger))
+	r.Use(middleware.Timeout(60 * time.Second))
+
+	r.Get("/greeting", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("welcome"))
+	})
+
+	r.Get("/health", health)
+
+	r.Route("/manager", func(r chi.Router) {
+		r.Post("/tasks", managerApi.StartTaskHandler)
+		r.Get("/tasks", managerApi.GetTasksHandler)
+		r.Get("/tasks/{taskID}", managerApi.GetTaskHandler)
+		r.Delete("/tasks/{taskID}", managerApi.StopTaskHandler)
+		r.Get("/nodes", managerApi.GetNodesHandler)
+		r.Post("/nodes/{name}/taints", managerApi.AddTaintHandler)
+		r.Delete("/nodes/{name}/taints/{key}", managerApi.RemoveTaintHandler)
+		r.Post("/nodes/{name}/cordon", managerApi.CordonHandler)
+		r.Delete("/nodes/{name}/cordon", managerApi.UncordonHandler)
+		r.Post("/nodes/{name}/drain", managerApi.DrainHandler)
+		r.Post("/nodes", managerApi.RegisterNodeHandler)
+		r.Put("/nodes/{name}/heartbeat", managerApi.HeartbeatHandler)
+		r.Post("/groups", managerApi.StartGroupHandler)
+		r.Get("/groups", managerApi.GetGroupsHandler)
+		r.Get("/events", managerApi.GetEventsHandler)
+		r.Post("/schedule/explain", managerApi.ExplainHandler)
+	})
+
+	return r
+}
+
+func NewScheduler(cfg config.Manager) (*scheduler.Profiles, error) {
+	return scheduler.NewProfiles(cfg.Scheduler, cfg.Profiles)
+}
+
+func NewManagerBolt(lifecycle fx.Lifecycle, cfg config.Manager) (*bbolt.DB, error) {
+	return openBolt(lifecycle, cfg.DataDir, "maestro.db")
+}
+
+func runManagerLoops(lifecycle fx.Lifecycle, m *manager.Manager, cfg config.Manager, logger *httplog.Logger) {
+	g := loop.NewGroup(logger)
+	lifecycle.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			err := m.RunLoops(g, manager.LoopConfig{
+				DispatchInterval:  cfg.DispatchInterval,
+				ReconcileInterval: cfg.ReconcileInterval,
+				HealthInterval:    cfg.HealthInterval,
+			})
+			if err != nil {
+				// Stop hooks only run for hooks that started.
+				return errors.Join(err, g.Stop(ctx))
+			}
+			return nil
+		},
+		OnStop: g.Stop,
+	})
+}
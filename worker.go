@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/httplog/v2"
+	"github.com/nduyhai/maestro/client"
+	"github.com/nduyhai/maestro/internal/config"
+	"github.com/nduyhai/maestro/internal/httpx"
+	"github.com/nduyhai/maestro/internal/loop"
+	"github.com/nduyhai/maestro/internal/server"
+	"github.com/nduyhai/maestro/internal/task"
+	"github.com/nduyhai/maestro/internal/worker"
+	"go.uber.org/fx"
+)
+
+func newWorkerApp(cfg config.Worker) *fx.App {
+	return fx.New(
+		fx.Supply(cfg),
+		fx.Supply(server.Config{Addr: cfg.Addr}),
+		fx.Supply(NewLogger(cfg.LogLevel)),
+		fx.Provide(NewRuntime),
+		fx.Provide(NewWorkerStore),
+		fx.Provide(NewWorker),
+		fx.Provide(worker.NewAPI),
+
+		fx.Provide(fx.Annotate(NewWorkerRoute, fx.As(new(http.Handler)))),
+		// Hooks start in order: tasks are recovered before the API serves.
+		fx.Invoke(recoverWorker),
+		fx.Invoke(server.RegisterRoutes),
+		fx.Invoke(runWorker),
+	)
+}
+
+func NewWorkerRoute(logger *httplog.Logger, workerApi *worker.API) *chi.Mux {
+	r := chi.NewRouter()
+	r.Use(middleware.RealIP)
+	r.Use(httplog.RequestLogger(logger))
+	r.Use(middleware.Timeout(60 * time.Second))
+
+	r.Get("/greeting", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("welcome"))
+	})
+
+	r.Get("/health", health)
+
+	r.Post("/tasks", workerApi.StartTaskHandler)
+	r.Get("/tasks", workerApi.GetTasksHandler)
+	r.Delete("/tasks/{taskID}", workerApi.StopTaskHandler)
+	r.Get("/stats", workerApi.CollectStats)
+	r.Get("/info", workerApi.InfoHandler)
+
+	return r
+}
+
+func NewRuntime(cfg config.Worker, logger *httplog.Logger) (task.Runtime, error) {
+	if cfg.Runtime == "fake" {
+		return task.NewFake(), nil
+	}
+	return task.NewDocker(logger)
+}
+
+func NewWorker(cfg config.Worker, runtime task.Runtime, store *worker.Store, logger *httplog.Logger) (*worker.Worker, error) {
+	w := worker.NewWorker(runtime, store, cfg.Concurrency, logger)
+	w.Name = cfg.Name
+	w.Zone = cfg.Zone
+	w.Rack = cfg.Rack
+	w.Labels = cfg.Labels
+	for _, s := range cfg.Taints {
+		t, err := task.ParseTaint(s)
+		if err != nil {
+			return nil, err
+		}
+		w.Taints = append(w.Taints, t)
+	}
+	return w, nil
+}
+
+func NewWorkerStore(lifecycle fx.Lifecycle, cfg config.Worker) (*worker.Store, error) {
+	db, err := openBolt(lifecycle, cfg.DataDir, "maestro-worker.db")
+	if err != nil {
+		return nil, err
+	}
+	return worker.NewBoltStore(db)
+}
+
+// recoverWorker reloads the tasks of the worker and reconciles them with its
+// containers on startup.
+func recoverWorker(lifecycle fx.Lifecycle, w *worker.Worker) {
+	lifecycle.Append(fx.Hook{OnStart: w.Recover})
+}
+
+func runWorker(lifecycle fx.Lifecycle, w *worker.Worker, cfg config.Worker, logger *httplog.Logger) {
+	g := loop.NewGroup(logger)
+	var h *heartbeater
+	if cfg.ManagerURL != "" {
+		h = &heartbeater{Worker: w, Manager: client.NewManager(cfg.ManagerURL), URL: cfg.AdvertiseURL, Logger: logger}
+		// Stop hooks run in reverse: the client is closed after the loops.
+		lifecycle.Append(fx.Hook{OnStop: func(context.Context) error { return h.Manager.Close() }})
+	}
+	lifecycle.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			logger.Info("starting tasks", slog.Int("concurrency", cfg.Concurrency))
+			err := w.Run(g, cfg.UpdateInterval, cfg.HealthCheckInterval)
+			if err == nil && h != nil {
+				err = g.Every("heartbeat", cfg.HeartbeatInterval, nil, h.beat)
+			}
+			if err != nil {
+				// Stop hooks only run for hooks that started.
+				return errors.Join(err, g.Stop(ctx))
+			}
+			return nil
+		},
+		OnStop: g.Stop,
+	})
+}
+
+// heartbeater registers the worker with the manager, then sends it
+// heartbeats. It registers again when the manager no longer knows the node,
+// e.g. after the manager lost its state.
+type heartbeater struct {
+	Worker  *worker.Worker
+	Manager *client.Client
+	URL     string
+	Logger  *httplog.Logger
+
+	node string
+}
+
+func (h *heartbeater) beat(ctx context.Context) {
+	if h.node == "" {
+		name, err := h.Manager.Register(ctx, h.Worker.Registration(h.URL))
+		if err != nil {
+			h.Logger.Error("Error registering with the manager", slog.Any("err", err))
+			return
+		}
+		h.node = name
+		h.Logger.Info("Registered with the manager", slog.String("node", name), slog.String("url", h.URL))
+		return
+	}
+
+	err := h.Manager.Heartbeat(ctx, h.node, h.Worker.Heartbeat())
+	var e *httpx.ErrResponse
+	if errors.As(err, &e) && e.HTTPStatusCode == http.StatusNotFound {
+		h.Logger.Info("Manager does not know this node, registering again", slog.String("node", h.node))
+		h.node = ""
+		return
+	}
+	if err != nil {
+		h.Logger.Error("Error sending heartbeat", slog.Any("err", err))
+	}
+}